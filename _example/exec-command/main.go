@@ -28,6 +28,13 @@ func main() {
 	p, _ := prompt.New(
 		executor,
 		completer,
+		// Ctrl+C while bash is running is meant for bash, not for this prompt.
+		prompt.OptionSignalHandler(func(sig os.Signal) prompt.SignalAction {
+			if sig == os.Interrupt {
+				return prompt.SignalForward
+			}
+			return prompt.SignalReturn
+		}),
 	)
 	p.Run()
 }
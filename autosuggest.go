@@ -0,0 +1,71 @@
+package prompt
+
+import (
+	"strings"
+	"unicode"
+)
+
+// AutoSuggester returns a line completing the text of the document, like the autosuggestions of fish.
+// The rest of the line after the text is shown as ghost text after the cursor, nothing is shown when it doesn't start with the text.
+type AutoSuggester func(d Document) string
+
+// HistoryAutoSuggester suggests the newest history entry starting with the text of the document.
+func HistoryAutoSuggester(h *History) AutoSuggester {
+	return func(d Document) string {
+		for i := len(h.histories) - 1; i >= 0; i-- {
+			if e := h.histories[i]; len(e) > len(d.Text) && strings.HasPrefix(e, d.Text) {
+				return e
+			}
+		}
+		return ""
+	}
+}
+
+// autoSuggestion returns the suggested text after the buffer. There is no suggestion unless the cursor is at the end
+// of a non-empty buffer, and the selected completion or the history search is shown instead of it.
+func (p *Prompt) autoSuggestion() string {
+	if p.autoSuggester == nil || p.search.active {
+		return ""
+	}
+	if _, ok := p.completion.GetSelectedSuggestion(); ok {
+		return ""
+	}
+	d := p.buf.Document()
+	if d.Text == "" || d.TextAfterCursor() != "" {
+		return ""
+	}
+	line := p.autoSuggester(*d)
+	if !strings.HasPrefix(line, d.Text) {
+		return ""
+	}
+	return line[len(d.Text):]
+}
+
+// handleAutoSuggestionKeyBinding accepts the suggestion with Right or End, or its next word with Alt+F.
+func (p *Prompt) handleAutoSuggestionKeyBinding(key Key) bool {
+	if key != Right && key != End && key != AltF {
+		return false
+	}
+	suggestion := p.autoSuggestion()
+	if suggestion == "" {
+		return false
+	}
+	if key == AltF {
+		suggestion = firstWord(suggestion)
+	}
+	p.buf.InsertText(suggestion, false, true)
+	return true
+}
+
+// firstWord returns the text up to the end of its first word, including the spaces before it.
+func firstWord(text string) string {
+	runes := []rune(text)
+	i := 0
+	for i < len(runes) && unicode.IsSpace(runes[i]) {
+		i++
+	}
+	for i < len(runes) && !unicode.IsSpace(runes[i]) {
+		i++
+	}
+	return string(runes[:i])
+}
@@ -0,0 +1,103 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoSuggestion(t *testing.T) {
+	const (
+		right = "\x1b[C"
+		left  = "\x1b[D"
+		end   = "\x1b[F"
+		altF  = "\x1bf"
+	)
+	histories := []string{"select * from orders", "show tables", "select 1", "select * from users"}
+
+	scenarios := []struct {
+		name       string
+		typed      string
+		keys       []string
+		text       string
+		suggestion string
+	}{
+		{name: "newest match", typed: "sel", text: "sel", suggestion: "ect * from users"},
+		{name: "older match", typed: "select * from o", text: "select * from o", suggestion: "rders"},
+		{name: "no match", typed: "describe", text: "describe"},
+		{name: "nothing for an empty buffer", text: ""},
+		{name: "whole entry is typed", typed: "show tables", text: "show tables"},
+		{name: "right accepts", typed: "sh", keys: []string{right}, text: "show tables"},
+		{name: "end accepts", typed: "sh", keys: []string{end}, text: "show tables"},
+		{name: "alt+f accepts a word", typed: "sel", keys: []string{altF}, text: "select", suggestion: " * from users"},
+		{name: "alt+f accepts the next word", typed: "sel", keys: []string{altF, altF}, text: "select *", suggestion: " from users"},
+		{name: "nothing when the cursor is not at the end", typed: "sel", keys: []string{left}, text: "sel"},
+		{name: "right moves the cursor when the cursor is not at the end", typed: "sel", keys: []string{left, right}, text: "sel", suggestion: "ect * from users"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			p := newTestPrompt(EmacsKeyBind)
+			p.autoSuggester = HistoryAutoSuggester(p.history)
+			for _, h := range histories {
+				p.history.Add(h)
+			}
+			p.buf.InsertText(s.typed, false, true)
+
+			for _, k := range s.keys {
+				_, err := p.feed([]byte(k))
+				require.NoError(t, err)
+			}
+			require.Equal(t, s.text, p.buf.Text())
+			require.Equal(t, s.suggestion, p.autoSuggestion())
+		})
+	}
+}
+
+func TestAutoSuggestionWithSelectedCompletion(t *testing.T) {
+	p := newTestPrompt(EmacsKeyBind)
+	p.completion = NewCompletionManager(func(Document) []Suggest {
+		return []Suggest{{Text: "select"}}
+	}, 1)
+	p.autoSuggester = func(Document) string { return "sel * from t" }
+	p.buf.InsertText("sel", false, true)
+	require.Equal(t, " * from t", p.autoSuggestion())
+
+	p.completion.Update(*p.buf.Document())
+	p.completion.Next()
+	require.Equal(t, "", p.autoSuggestion())
+}
+
+func TestAutoSuggester(t *testing.T) {
+	p := &Prompt{
+		buf:           NewBuffer(),
+		completion:    NewCompletionManager(nil, 0),
+		autoSuggester: func(d Document) string { return "other" },
+	}
+	p.buf.InsertText("sel", false, true)
+	// a line which doesn't complete the text is not shown
+	require.Equal(t, "", p.autoSuggestion())
+
+	p.autoSuggester = func(d Document) string { return d.Text + "ect" }
+	require.Equal(t, "ect", p.autoSuggestion())
+}
+
+func TestRenderAutoSuggestion(t *testing.T) {
+	out := &recordingWriter{}
+	r := &Render{
+		prefix:             "> ",
+		out:                out,
+		livePrefixCallback: func() (string, bool) { return "", false },
+		col:                80,
+		autoSuggestion:     "ect 1",
+		theme:              Theme{AutoSuggestion: Style{Fg: DarkGray}},
+	}
+	buf := NewBuffer()
+	buf.InsertText("sel", false, true)
+	r.Render(buf, NotDefined, NewCompletionManager(nil, 0), NewLexer(), nil)
+
+	require.Contains(t, out.String(), "sel\x1b[0;90;49mect 1")
+	require.Equal(t, "sel", buf.Text())
+	// the cursor is moved back after the buffer
+	require.Equal(t, len("> sel"), r.previousCursor)
+}
@@ -2,6 +2,7 @@ package prompt
 
 import (
 	"strings"
+	"unicode"
 
 	"github.com/confluentinc/go-prompt/internal/debug"
 )
@@ -14,8 +15,43 @@ type Buffer struct {
 	cacheDocument   *Document
 	preferredColumn int // Remember the original column for the next up/down movement.
 	lastKeyStroke   Key
+	killRing        *KillRing
+	command         bufferCommand // What the running command did, e.g. killing text.
+	lastCommand     bufferCommand // What the previous command did, so that consecutive kills are appended together.
+	yankStart       int           // Start of the text inserted by the last yank.
+	yankLength      int           // Length of the text inserted by the last yank.
+	undoStack       []bufferState
+	redoStack       []bufferState
+	commandStart    bufferState // State before the running command.
+	lastEdit        bufferEdit  // Kind of the last undo step, consecutive typing or deleting is merged into it.
+	lastEditEnd     bufferState // State after the last undo step.
 }
 
+// bufferState is the text and the cursor position which Undo and Redo restore.
+type bufferState struct {
+	text   string
+	cursor int
+}
+
+// bufferEdit tells how a command changed the text, to group the undo steps.
+type bufferEdit int
+
+const (
+	editOther bufferEdit = iota
+	editTyping
+	editDeleting
+)
+
+// bufferCommand tells what a command did to the buffer.
+type bufferCommand int
+
+const (
+	commandOther bufferCommand = iota
+	commandKill
+	commandYank
+	commandUndo
+)
+
 // Text returns string of the current line.
 func (b *Buffer) Text() string {
 	return b.workingLines[b.workingIndex]
@@ -200,6 +236,175 @@ func (b *Buffer) SwapCharactersBeforeCursor() {
 	}
 }
 
+// TransposeWords swaps the word before the cursor with the word after it and moves the cursor after them,
+// like transpose-words of readline. At the end of the text, the last two words are swapped.
+func (b *Buffer) TransposeWords() {
+	text := []rune(b.Text())
+	forward := func(i int) int {
+		for i < len(text) && unicode.IsSpace(text[i]) {
+			i++
+		}
+		for i < len(text) && !unicode.IsSpace(text[i]) {
+			i++
+		}
+		return i
+	}
+	backward := func(i int) int {
+		for i > 0 && unicode.IsSpace(text[i-1]) {
+			i--
+		}
+		for i > 0 && !unicode.IsSpace(text[i-1]) {
+			i--
+		}
+		return i
+	}
+	start2 := backward(forward(b.cursorPosition))
+	end2 := forward(start2)
+	start1 := backward(start2)
+	end1 := forward(start1)
+	if start1 == start2 || start2 < end1 {
+		return
+	}
+
+	swapped := string(text[:start1]) + string(text[start2:end2]) + string(text[end1:start2]) + string(text[start1:end1]) + string(text[end2:])
+	b.setText(swapped)
+	b.setCursorPosition(end2)
+}
+
+// KillRing returns the kill ring used by Kill, KillBeforeCursor, Yank and YankPop.
+// A prompt shares its kill ring with all its buffers.
+func (b *Buffer) KillRing() *KillRing {
+	if b.killRing == nil {
+		b.killRing = NewKillRing(DefaultKillRingSize)
+	}
+	return b.killRing
+}
+
+// Kill deletes count characters after the cursor and adds them to the kill ring.
+// The text is appended to the newest entry of the kill ring when the previous command killed text as well.
+func (b *Buffer) Kill(count int) (killed string) {
+	r := []rune(b.Text())
+	end := min(b.cursorPosition+count, len(r))
+	if b.cursorPosition >= end {
+		return ""
+	}
+	killed = string(r[b.cursorPosition:end])
+	b.setText(string(r[:b.cursorPosition]) + string(r[end:]))
+	b.KillRing().kill(killed, b.lastCommand == commandKill, false)
+	b.command = commandKill
+	return killed
+}
+
+// KillBeforeCursor deletes count characters before the cursor and adds them to the kill ring.
+// The text is prepended to the newest entry of the kill ring when the previous command killed text as well.
+func (b *Buffer) KillBeforeCursor(count int) (killed string) {
+	killed = b.DeleteBeforeCursor(count)
+	if killed == "" {
+		return ""
+	}
+	b.KillRing().kill(killed, b.lastCommand == commandKill, true)
+	b.command = commandKill
+	return killed
+}
+
+// Yank inserts the newest entry of the kill ring at the cursor.
+func (b *Buffer) Yank() {
+	text, ok := b.KillRing().yank()
+	if !ok {
+		return
+	}
+	b.insertYanked(text)
+}
+
+// YankPop replaces the text inserted by the previous Yank or YankPop with the previous entry of the kill ring.
+// It does nothing when the previous command was not a yank.
+func (b *Buffer) YankPop() {
+	if b.lastCommand != commandYank {
+		return
+	}
+	text, ok := b.KillRing().rotate()
+	if !ok {
+		return
+	}
+	r := []rune(b.Text())
+	b.cursorPosition = b.yankStart
+	b.setText(string(r[:b.yankStart]) + string(r[b.yankStart+b.yankLength:]))
+	b.insertYanked(text)
+}
+
+func (b *Buffer) insertYanked(text string) {
+	b.yankStart = b.cursorPosition
+	b.yankLength = len([]rune(text))
+	b.InsertText(text, false, true)
+	b.command = commandYank
+}
+
+// Undo reverts the last change of the text and reports whether there was a change to revert.
+// Typed words, deleted characters, completions and kills are reverted as a whole.
+func (b *Buffer) Undo() bool {
+	if len(b.undoStack) == 0 {
+		return false
+	}
+	b.redoStack = append(b.redoStack, b.state())
+	b.restore(b.undoStack[len(b.undoStack)-1])
+	b.undoStack = b.undoStack[:len(b.undoStack)-1]
+	return true
+}
+
+// Redo applies the last change reverted by Undo again and reports whether there was a change to apply.
+func (b *Buffer) Redo() bool {
+	if len(b.redoStack) == 0 {
+		return false
+	}
+	b.undoStack = append(b.undoStack, b.state())
+	b.restore(b.redoStack[len(b.redoStack)-1])
+	b.redoStack = b.redoStack[:len(b.redoStack)-1]
+	return true
+}
+
+func (b *Buffer) state() bufferState {
+	return bufferState{text: b.Text(), cursor: b.cursorPosition}
+}
+
+func (b *Buffer) restore(s bufferState) {
+	b.cursorPosition = 0
+	b.setText(s.text)
+	b.setCursorPosition(s.cursor)
+	b.command = commandUndo
+	b.lastEdit = editOther
+}
+
+// beginCommand is called before a key is handled, to know what the previous command did.
+func (b *Buffer) beginCommand() {
+	b.lastCommand, b.command = b.command, commandOther
+	b.commandStart = b.state()
+}
+
+// endCommand is called after a key is handled, to add the change of the text to the undo steps.
+func (b *Buffer) endCommand(edit bufferEdit) {
+	if b.command == commandUndo || b.Text() == b.commandStart.text {
+		return
+	}
+	if edit == editOther || edit != b.lastEdit || b.commandStart != b.lastEditEnd || (edit == editTyping && b.startsWord()) {
+		b.undoStack = append(b.undoStack, b.commandStart)
+	}
+	b.redoStack = nil
+	b.lastEdit, b.lastEditEnd = edit, b.state()
+}
+
+// startsWord reports whether the running command typed the first character of a word.
+func (b *Buffer) startsWord() bool {
+	text := []rune(b.Text())
+	start := b.commandStart.cursor
+	end := start + len(text) - len([]rune(b.commandStart.text))
+	for i := max(start, 1); i < min(end, len(text)); i++ {
+		if unicode.IsSpace(text[i-1]) && !unicode.IsSpace(text[i]) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewBuffer is constructor of Buffer struct.
 func NewBuffer() (b *Buffer) {
 	b = &Buffer{
@@ -3,6 +3,8 @@ package prompt
 import (
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewBuffer(t *testing.T) {
@@ -196,3 +198,76 @@ func TestBuffer_SwapCharactersBeforeCursor(t *testing.T) {
 		t.Errorf("Should be %#v, got %#v", ex, ac)
 	}
 }
+
+func TestBuffer_TransposeWords(t *testing.T) {
+	scenarios := []struct {
+		text       string
+		cursor     int
+		want       string
+		wantCursor int
+	}{
+		{text: "select from table", cursor: 6, want: "from select table", wantCursor: 11},
+		{text: "select from table", cursor: 8, want: "from select table", wantCursor: 11},
+		{text: "select from table", cursor: 17, want: "select table from", wantCursor: 17},
+		{text: "select from table  ", cursor: 19, want: "select table from  ", wantCursor: 17},
+		{text: "select from", cursor: 0, want: "select from", wantCursor: 0},
+		{text: "select", cursor: 6, want: "select", wantCursor: 6},
+		{text: "日本 語", cursor: 2, want: "語 日本", wantCursor: 4},
+	}
+
+	for _, s := range scenarios {
+		b := NewBuffer()
+		b.InsertText(s.text, false, false)
+		b.cursorPosition = s.cursor
+		b.TransposeWords()
+		require.Equal(t, s.want, b.Text())
+		require.Equal(t, s.wantCursor, b.cursorPosition)
+	}
+}
+
+func TestBuffer_KillAndYank(t *testing.T) {
+	b := NewBuffer()
+	b.InsertText("foo bar baz", false, true)
+
+	// consecutive kills are joined
+	b.beginCommand()
+	b.KillBeforeCursor(4)
+	b.beginCommand()
+	b.KillBeforeCursor(4)
+	require.Equal(t, "foo", b.Text())
+	require.Equal(t, []string{" bar baz"}, b.KillRing().Entries())
+
+	b.beginCommand()
+	b.CursorLeft(3)
+	b.beginCommand()
+	b.Kill(3)
+	require.Equal(t, "", b.Text())
+	require.Equal(t, []string{"foo", " bar baz"}, b.KillRing().Entries())
+
+	b.beginCommand()
+	b.Yank()
+	require.Equal(t, "foo", b.Text())
+	b.beginCommand()
+	b.YankPop()
+	require.Equal(t, " bar baz", b.Text())
+	b.beginCommand()
+	b.YankPop()
+	require.Equal(t, "foo", b.Text())
+	require.Equal(t, 3, b.cursorPosition)
+
+	// yank-pop only works right after a yank
+	b.beginCommand()
+	b.CursorLeft(1)
+	b.beginCommand()
+	b.YankPop()
+	require.Equal(t, "foo", b.Text())
+}
+
+func TestKillRing(t *testing.T) {
+	k := NewKillRing(2)
+	k.Push("a")
+	k.Push("b")
+	k.Push("c")
+	require.Equal(t, []string{"c", "b"}, k.Entries())
+	require.Equal(t, 2, k.Len())
+}
@@ -0,0 +1,230 @@
+package prompt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// the bits telling the 256 colors and the 24-bit colors from the 16 ANSI colors.
+	colorIndexed Color = 1 << 24
+	colorRGB     Color = 1 << 25
+)
+
+// Color256 returns the color n of the 256 colors palette: the 16 ANSI colors, a 6x6x6 color cube from 16 to 231
+// and a grayscale ramp from 232 to 255.
+func Color256(n uint8) Color {
+	return colorIndexed | Color(n)
+}
+
+// RGB returns a 24-bit color.
+func RGB(r, g, b uint8) Color {
+	return colorRGB | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// Hex returns the 24-bit color of a hexadecimal string like "#ff8800" or "#f80",
+// and DefaultColor when s isn't one.
+func Hex(s string) Color {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	n, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 6 || err != nil {
+		return DefaultColor
+	}
+	return colorRGB | Color(n)
+}
+
+// ColorDepth is the number of colors a terminal can show. The colors it can't show are replaced with the nearest ones.
+type ColorDepth int
+
+const (
+	// ColorDepthNone is no colors, only the attributes like bold are shown.
+	ColorDepthNone ColorDepth = iota + 1
+	// ColorDepth16 is the 16 ANSI colors.
+	ColorDepth16
+	// ColorDepth256 is the 256 colors palette.
+	ColorDepth256
+	// ColorDepthTrueColor is the 24-bit colors.
+	ColorDepthTrueColor
+)
+
+// ansiPalette is the RGB value of each of the 16 ANSI colors, from Black to White, as xterm shows them.
+var ansiPalette = [16][3]uint8{
+	{0x00, 0x00, 0x00}, {0xcd, 0x00, 0x00}, {0x00, 0xcd, 0x00}, {0xcd, 0xcd, 0x00},
+	{0x00, 0x00, 0xee}, {0xcd, 0x00, 0xcd}, {0x00, 0xcd, 0xcd}, {0xe5, 0xe5, 0xe5},
+	{0x7f, 0x7f, 0x7f}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+	{0x5c, 0x5c, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// cubeLevels are the values of the red, the green and the blue of the color cube of the 256 colors palette.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// isANSI returns whether c is one of the 16 ANSI colors.
+func (c Color) isANSI() bool {
+	return c >= Black && c <= White
+}
+
+// rgb returns the red, the green and the blue of a color which isn't DefaultColor.
+func (c Color) rgb() (r, g, b uint8) {
+	switch {
+	case c&colorRGB != 0:
+		return uint8(c >> 16), uint8(c >> 8), uint8(c)
+	case c&colorIndexed != 0:
+		n := int(uint8(c))
+		switch {
+		case n < 16:
+			p := ansiPalette[n]
+			return p[0], p[1], p[2]
+		case n < 232:
+			n -= 16
+			return cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]
+		default:
+			v := uint8(8 + 10*(n-232))
+			return v, v, v
+		}
+	case c.isANSI():
+		p := ansiPalette[c-Black]
+		return p[0], p[1], p[2]
+	}
+	return 0, 0, 0
+}
+
+// degrade returns the nearest color to c which a terminal with the color depth shows,
+// DefaultColor when it shows none. A zero depth keeps the color.
+func (c Color) degrade(depth ColorDepth) Color {
+	switch {
+	case depth == ColorDepthNone:
+		return DefaultColor
+	case depth == 0 || c == DefaultColor || c.isANSI():
+		return c
+	case c&colorIndexed != 0 && uint8(c) < 16:
+		return Black + Color(uint8(c))
+	case depth == ColorDepth256 && c&colorRGB != 0:
+		return Color256(nearest256(c.rgb()))
+	case depth == ColorDepth16 && (c&colorIndexed != 0 || c&colorRGB != 0):
+		r, g, b := c.rgb()
+		best, bestDistance := 0, -1
+		for i, p := range ansiPalette {
+			if d := colorDistance(r, g, b, p[0], p[1], p[2]); bestDistance < 0 || d < bestDistance {
+				best, bestDistance = i, d
+			}
+		}
+		return Black + Color(best)
+	}
+	return c
+}
+
+// nearest256 returns the index of the color of the cube or of the grayscale ramp of the 256 colors palette nearest to r, g, b.
+func nearest256(r, g, b uint8) uint8 {
+	level := func(v uint8) int {
+		best := 0
+		for i, l := range cubeLevels {
+			if absDiff(v, l) < absDiff(v, cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := level(r), level(g), level(b)
+	cube := uint8(16 + 36*ri + 6*gi + bi)
+	cubeDistance := colorDistance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	average := (int(r) + int(g) + int(b)) / 3
+	grayIndex := min(max((average-3)/10, 0), 23)
+	gray := uint8(8 + 10*grayIndex)
+	if colorDistance(r, g, b, gray, gray, gray) < cubeDistance {
+		return uint8(232 + grayIndex)
+	}
+	return cube
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr, dg, db := absDiff(r1, r2), absDiff(g1, g2), absDiff(b1, b2)
+	return dr*dr + dg*dg + db*db
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// colorParameters returns the parameters of the SGR sequence setting the text or the background color c,
+// e.g. 31, 38;5;208 or 48;2;255;136;0.
+func colorParameters(c Color, background bool) []byte {
+	table, extended := foregroundANSIColors, "38"
+	if background {
+		table, extended = backgroundANSIColors, "48"
+	}
+	switch {
+	case c&colorRGB != 0:
+		r, g, b := c.rgb()
+		return []byte(extended + ";2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b)))
+	case c&colorIndexed != 0:
+		return []byte(extended + ";5;" + strconv.Itoa(int(uint8(c))))
+	}
+	if p, ok := table[c]; ok {
+		return p
+	}
+	return table[DefaultColor]
+}
+
+// colorNames are the names of the ANSI colors in the theme files.
+var colorNames = map[Color]string{
+	DefaultColor: "default",
+	Black:        "black",
+	DarkRed:      "darkred",
+	DarkGreen:    "darkgreen",
+	Brown:        "brown",
+	DarkBlue:     "darkblue",
+	Purple:       "purple",
+	Cyan:         "cyan",
+	LightGray:    "lightgray",
+	DarkGray:     "darkgray",
+	Red:          "red",
+	Green:        "green",
+	Yellow:       "yellow",
+	Blue:         "blue",
+	Fuchsia:      "fuchsia",
+	Turquoise:    "turquoise",
+	White:        "white",
+}
+
+// MarshalText encodes the color as its name, its index in the 256 colors palette or its hexadecimal value.
+func (c Color) MarshalText() ([]byte, error) {
+	switch {
+	case c&colorRGB != 0:
+		r, g, b := c.rgb()
+		return []byte(fmt.Sprintf("#%02x%02x%02x", r, g, b)), nil
+	case c&colorIndexed != 0:
+		return []byte(strconv.Itoa(int(uint8(c)))), nil
+	}
+	if name, ok := colorNames[c]; ok {
+		return []byte(name), nil
+	}
+	return nil, fmt.Errorf("invalid color %d", int(c))
+}
+
+// UnmarshalText decodes a color encoded by MarshalText. The names are case-insensitive.
+func (c *Color) UnmarshalText(text []byte) error {
+	s := string(text)
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil {
+		*c = Color256(uint8(n))
+		return nil
+	}
+	if v := Hex(s); strings.HasPrefix(s, "#") && v != DefaultColor {
+		*c = v
+		return nil
+	}
+	for color, name := range colorNames {
+		if strings.EqualFold(s, name) {
+			*c = color
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid color %q", s)
+}
@@ -0,0 +1,48 @@
+package prompt
+
+import (
+	"os"
+	"strings"
+)
+
+// DetectColorDepth returns the colors shown by the terminal writing to f:
+//   - none when the NO_COLOR environment variable is set, see https://no-color.org, when f isn't a terminal or when $TERM is dumb,
+//   - the 24-bit colors when $COLORTERM is truecolor or 24bit,
+//   - the colors of the terminfo entry of $TERM when there is one,
+//   - the 256 colors when $TERM contains 256color, and the 16 ANSI colors otherwise.
+//
+// OptionColorDepth overrides it.
+func DetectColorDepth(f *os.File) ColorDepth {
+	return detectColorDepth(os.Getenv, isTerminal(f), terminalColorDepth(os.Getenv("TERM")))
+}
+
+// detectColorDepth is DetectColorDepth with the environment variables of getenv, whether the output is a terminal
+// and the colors of the terminal, 0 when they are not known.
+func detectColorDepth(getenv func(string) string, terminal bool, terminalDepth ColorDepth) ColorDepth {
+	term := getenv("TERM")
+	switch colorTerm := strings.ToLower(getenv("COLORTERM")); {
+	case getenv("NO_COLOR") != "" || !terminal || term == "dumb":
+		return ColorDepthNone
+	case colorTerm == "truecolor" || colorTerm == "24bit":
+		return ColorDepthTrueColor
+	case terminalDepth != 0:
+		return terminalDepth
+	case strings.Contains(term, "256color"):
+		return ColorDepth256
+	}
+	return ColorDepth16
+}
+
+// isPlainOutput returns whether f isn't a terminal, e.g. a CI log, or is a dumb terminal, e.g. with a screen reader.
+// The display attributes are left out as well as the colors, while only the colors are with NO_COLOR.
+func isPlainOutput(f *os.File) bool {
+	return !isTerminal(f) || os.Getenv("TERM") == "dumb"
+}
+
+// setColorDepth sets the colors used to render, and those of the writer when it has a SetColorDepth method like VT100Writer.
+func (r *Render) setColorDepth(depth ColorDepth) {
+	r.colorDepth = depth
+	if w, ok := r.out.(interface{ SetColorDepth(ColorDepth) }); ok {
+		w.SetColorDepth(depth)
+	}
+}
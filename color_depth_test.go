@@ -0,0 +1,76 @@
+package prompt
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectColorDepth(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		env           map[string]string
+		terminal      bool
+		terminalDepth ColorDepth
+		want          ColorDepth
+	}{
+		{name: "NO_COLOR", env: map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}, terminal: true, want: ColorDepthNone},
+		{name: "empty NO_COLOR", env: map[string]string{"NO_COLOR": "", "TERM": "xterm"}, terminal: true, want: ColorDepth16},
+		{name: "not a terminal", env: map[string]string{"TERM": "xterm-256color"}, want: ColorDepthNone},
+		{name: "dumb", env: map[string]string{"TERM": "dumb"}, terminal: true, want: ColorDepthNone},
+		{name: "truecolor", env: map[string]string{"TERM": "xterm", "COLORTERM": "truecolor"}, terminal: true, terminalDepth: ColorDepth16, want: ColorDepthTrueColor},
+		{name: "24bit", env: map[string]string{"COLORTERM": "24BIT"}, terminal: true, want: ColorDepthTrueColor},
+		{name: "terminfo", env: map[string]string{"TERM": "xterm-256color"}, terminal: true, terminalDepth: ColorDepthNone, want: ColorDepthNone},
+		{name: "256color", env: map[string]string{"TERM": "screen-256color"}, terminal: true, want: ColorDepth256},
+		{name: "default", env: map[string]string{}, terminal: true, want: ColorDepth16},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			getenv := func(key string) string { return s.env[key] }
+			require.Equal(t, s.want, detectColorDepth(getenv, s.terminal, s.terminalDepth))
+		})
+	}
+
+	// the output of the tests isn't a terminal
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	require.NoError(t, err)
+	defer f.Close()
+	require.Equal(t, ColorDepthNone, DetectColorDepth(f))
+}
+
+func TestOptionColorDepth(t *testing.T) {
+	out := &recordingWriter{}
+	p := &Prompt{renderer: &Render{out: out, theme: ThemeDark}}
+	require.NoError(t, OptionColorDepth(ColorDepthNone)(p))
+	require.Equal(t, ColorDepthNone, out.colorDepth)
+	p.renderer.setStyle(p.renderer.theme.SelectedSuggestion)
+	p.renderer.setStyle(Style{Fg: Hex("#ff8800")})
+	require.Equal(t, "\x1b[0;1m\x1b[0m", string(out.buffer))
+
+	// the writer set afterwards gets the depth as well
+	w := &recordingWriter{}
+	require.NoError(t, OptionColorDepth(ColorDepth256)(p))
+	require.NoError(t, OptionWriter(w)(p))
+	require.Equal(t, ColorDepth256, w.colorDepth)
+	p.renderer.setStyle(Style{Fg: Hex("#ff8800")})
+	require.Equal(t, "\x1b[0;38;5;208;49m", string(w.buffer))
+
+	// a writer without SetColorDepth gets the colors replaced by the renderer
+	nw := &noDepthWriter{}
+	p.renderer.out = nw
+	require.NoError(t, OptionColorDepth(ColorDepth16)(p))
+	p.renderer.setStyle(Style{Fg: Hex("#ff0000")})
+	require.Equal(t, "\x1b[0;91;49m", string(nw.w.buffer))
+}
+
+// noDepthWriter is a ConsoleWriter which doesn't have a SetColorDepth method.
+type noDepthWriter struct {
+	ConsoleWriter
+	w VT100Writer
+}
+
+func (w *noDepthWriter) SetDisplayAttributes(fg, bg Color, attrs ...DisplayAttribute) {
+	w.w.SetDisplayAttributes(fg, bg, attrs...)
+}
@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHex(t *testing.T) {
+	require.Equal(t, RGB(0xff, 0x88, 0x00), Hex("#ff8800"))
+	require.Equal(t, RGB(0xff, 0x88, 0x00), Hex("FF8800"))
+	require.Equal(t, RGB(0xff, 0x88, 0x00), Hex("#f80"))
+	for _, s := range []string{"", "#", "#ff88", "#gg8800", "#ff880000", "#-f8800"} {
+		require.Equal(t, DefaultColor, Hex(s), s)
+	}
+}
+
+func TestColorDegrade(t *testing.T) {
+	scenarios := []struct {
+		color Color
+		depth ColorDepth
+		want  Color
+	}{
+		{color: Red, depth: ColorDepth16, want: Red},
+		{color: DefaultColor, depth: ColorDepth16, want: DefaultColor},
+		{color: RGB(1, 2, 3), depth: 0, want: RGB(1, 2, 3)},
+		{color: RGB(1, 2, 3), depth: ColorDepthTrueColor, want: RGB(1, 2, 3)},
+		{color: Color256(208), depth: ColorDepthTrueColor, want: Color256(208)},
+		{color: Color256(208), depth: ColorDepth256, want: Color256(208)},
+		{color: Color256(9), depth: ColorDepth256, want: Red},
+		{color: RGB(0xff, 0x87, 0x00), depth: ColorDepth256, want: Color256(208)},
+		{color: RGB(0xff, 0x88, 0x01), depth: ColorDepth256, want: Color256(208)},
+		{color: RGB(0x80, 0x80, 0x80), depth: ColorDepth256, want: Color256(244)},
+		{color: RGB(0, 0, 0), depth: ColorDepth256, want: Color256(16)},
+		{color: RGB(0xff, 0xff, 0xff), depth: ColorDepth256, want: Color256(231)},
+		{color: RGB(0xf0, 0x10, 0x10), depth: ColorDepth16, want: Red},
+		{color: RGB(0x00, 0x00, 0xee), depth: ColorDepth16, want: DarkBlue},
+		{color: Color256(250), depth: ColorDepth16, want: LightGray},
+		{color: Color256(46), depth: ColorDepth16, want: Green},
+	}
+
+	for _, s := range scenarios {
+		t.Run(fmt.Sprintf("%x/%d", int(s.color), s.depth), func(t *testing.T) {
+			require.Equal(t, s.want, s.color.degrade(s.depth))
+		})
+	}
+}
+
+func TestColorParameters(t *testing.T) {
+	require.Equal(t, "31", string(colorParameters(DarkRed, false)))
+	require.Equal(t, "104", string(colorParameters(Blue, true)))
+	require.Equal(t, "39", string(colorParameters(Color(100), false)))
+	require.Equal(t, "38;5;208", string(colorParameters(Color256(208), false)))
+	require.Equal(t, "48;5;0", string(colorParameters(Color256(0), true)))
+	require.Equal(t, "38;2;255;136;0", string(colorParameters(Hex("#ff8800"), false)))
+	require.Equal(t, "48;2;1;2;3", string(colorParameters(RGB(1, 2, 3), true)))
+}
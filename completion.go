@@ -1,8 +1,10 @@
 package prompt
 
 import (
+	"context"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/confluentinc/go-prompt/internal/debug"
 	runewidth "github.com/mattn/go-runewidth"
@@ -14,6 +16,12 @@ const (
 	leftSuffix    = " "
 	rightPrefix   = " "
 	rightSuffix   = " "
+
+	// loadingText is displayed in the completion menu while a slow completer is running.
+	loadingText = "Loading..."
+	// loadingIndicatorDelay is how long the completer may run before the loading indicator is displayed,
+	// so that the menu doesn't flicker with fast completers.
+	loadingIndicatorDelay = 100 * time.Millisecond
 )
 
 // Suggest is printed when completing.
@@ -22,17 +30,27 @@ type Suggest struct {
 	Description string
 }
 
+// CompleterWithContext should return the suggest item from Document.
+// The context is canceled when the document changes before the suggestions are returned,
+// the suggestions are discarded then.
+type CompleterWithContext func(context.Context, Document) []Suggest
+
 // CompletionManager manages which suggestion is now selected.
 type CompletionManager struct {
 	selected  int // -1 means nothing one is selected.
 	tmp       []Suggest
 	max       uint16
-	completer Completer
+	completer CompleterWithContext
 
 	verticalScroll int
 	wordSeparator  string
 	showAtStart    bool
 
+	debounce time.Duration
+	loading  bool
+	version  uint64             // incremented for every update, suggestions of older versions are discarded.
+	cancel   context.CancelFunc // cancels the pending asynchronous update.
+
 	mu sync.RWMutex
 }
 
@@ -85,23 +103,114 @@ func (c *CompletionManager) reset() {
 	c.selected = -1
 	c.verticalScroll = 0
 	c.tmp = []Suggest{}
+	c.loading = false
 }
 
 // Update to update the suggestions.
+// It cancels the pending asynchronous update.
 func (c *CompletionManager) Update(in Document) {
-	if c.completer == nil {
+	c.mu.Lock()
+	version := c.nextVersion()
+	completer := c.completer
+	c.mu.Unlock()
+
+	if completer == nil {
 		return
 	}
-
-	updatedSuggestions := c.completer(in)
+	updatedSuggestions := completer(context.Background(), in)
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if version != c.version {
+		return
+	}
 	c.reset()
 	c.tmp = updatedSuggestions
 }
 
+// UpdateAsync updates the suggestions in the background after the debounce delay and calls notify
+// when the suggestions or the loading state have changed. It cancels the pending update, and suggestions
+// of a document which has changed in the meantime are discarded.
+// notify must not block after ctx is done.
+func (c *CompletionManager) UpdateAsync(ctx context.Context, in Document, notify func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	version := c.nextVersion()
+	if c.completer == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	go c.updateAsync(ctx, cancel, version, c.completer, c.debounce, in, notify)
+}
+
+func (c *CompletionManager) updateAsync(ctx context.Context, cancel context.CancelFunc, version uint64, completer CompleterWithContext, debounce time.Duration, in Document, notify func()) {
+	defer cancel()
+
+	if debounce > 0 {
+		t := time.NewTimer(debounce)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+	}
+
+	// Display the loading indicator if the completer is slow.
+	t := time.AfterFunc(loadingIndicatorDelay, func() {
+		c.mu.Lock()
+		if version != c.version || ctx.Err() != nil {
+			c.mu.Unlock()
+			return
+		}
+		c.reset()
+		c.loading = true
+		c.mu.Unlock()
+		notify()
+	})
+	suggestions := completer(ctx, in)
+	t.Stop()
+
+	c.mu.Lock()
+	if version != c.version || ctx.Err() != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.reset()
+	c.tmp = suggestions
+	c.mu.Unlock()
+	notify()
+}
+
+// CancelUpdate cancels the pending asynchronous update.
+func (c *CompletionManager) CancelUpdate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextVersion()
+}
+
+// nextVersion cancels the pending asynchronous update and returns the version of the next update.
+// c.mu must be held.
+func (c *CompletionManager) nextVersion() uint64 {
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+	c.loading = false
+	c.version++
+	return c.version
+}
+
+// Loading returns whether a slow completer is running.
+func (c *CompletionManager) Loading() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.loading
+}
+
 // Previous to select the previous suggestion item.
 func (c *CompletionManager) Previous() {
 	c.mu.Lock()
@@ -126,6 +235,28 @@ func (c *CompletionManager) Next() {
 	c.update()
 }
 
+// scroll scrolls the suggestions by delta rows, keeping the selected suggestion visible.
+func (c *CompletionManager) scroll(delta int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	height := min(int(c.max), len(c.tmp))
+	c.verticalScroll = max(0, min(c.verticalScroll+delta, len(c.tmp)-height))
+	if c.selected != -1 {
+		c.selected = max(c.verticalScroll, min(c.selected, c.verticalScroll+height-1))
+	}
+}
+
+// selectSuggestion selects the suggestion at index i, which is visible.
+func (c *CompletionManager) selectSuggestion(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if i >= 0 && i < len(c.tmp) {
+		c.selected = i
+	}
+}
+
 // Completing returns whether the CompletionManager selects something one.
 func (c *CompletionManager) Completing() bool {
 	c.mu.RLock()
@@ -146,6 +277,13 @@ func (c *CompletionManager) update() {
 	} else if c.selected < -1 {
 		c.selected = lenSuggestions - 1
 		c.verticalScroll = lenSuggestions - max
+	} else if c.selected != -1 {
+		// the menu may have been scrolled away from the selected suggestion with the mouse wheel
+		if c.selected < c.verticalScroll {
+			c.verticalScroll = c.selected
+		} else if c.selected >= c.verticalScroll+max {
+			c.verticalScroll = c.selected - max + 1
+		}
 	}
 }
 
@@ -224,11 +362,16 @@ func formatSuggestions(suggests []Suggest, max int) (new []Suggest, width int) {
 
 // NewCompletionManager returns initialized CompletionManager object.
 func NewCompletionManager(completer Completer, max uint16) *CompletionManager {
-	return &CompletionManager{
-		selected:  -1,
-		max:       max,
-		completer: completer,
+	c := &CompletionManager{
+		selected: -1,
+		max:      max,
 
 		verticalScroll: 0,
 	}
+	if completer != nil {
+		c.completer = func(_ context.Context, d Document) []Suggest {
+			return completer(d)
+		}
+	}
+	return c
 }
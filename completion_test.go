@@ -1,8 +1,13 @@
 package prompt
 
 import (
+	"context"
 	"reflect"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestFormatShortSuggestion(t *testing.T) {
@@ -204,3 +209,85 @@ func TestFormatText(t *testing.T) {
 		}
 	}
 }
+
+func TestCompletionManagerUpdateAsyncDiscardsOutdatedSuggestions(t *testing.T) {
+	release := make(chan struct{})
+	canceled := make(chan struct{})
+	c := NewCompletionManager(nil, 6)
+	c.completer = func(ctx context.Context, d Document) []Suggest {
+		if d.Text == "old" {
+			<-release
+			if ctx.Err() != nil {
+				close(canceled)
+			}
+		}
+		return []Suggest{{Text: d.Text}}
+	}
+
+	notified := make(chan struct{}, 2)
+	notify := func() { notified <- struct{}{} }
+	c.UpdateAsync(context.Background(), Document{Text: "old"}, notify)
+	c.UpdateAsync(context.Background(), Document{Text: "new"}, notify)
+	<-notified
+	require.Equal(t, []Suggest{{Text: "new"}}, c.GetSuggestions())
+
+	close(release)
+	<-canceled
+	require.Equal(t, []Suggest{{Text: "new"}}, c.GetSuggestions())
+	require.Empty(t, notified)
+}
+
+func TestCompletionManagerUpdateAsyncDebounce(t *testing.T) {
+	var calls atomic.Int32
+	c := NewCompletionManager(func(d Document) []Suggest {
+		calls.Add(1)
+		return []Suggest{{Text: d.Text}}
+	}, 6)
+	c.debounce = 50 * time.Millisecond
+
+	notified := make(chan struct{}, 3)
+	notify := func() { notified <- struct{}{} }
+	for _, text := range []string{"s", "se", "sel"} {
+		c.UpdateAsync(context.Background(), Document{Text: text}, notify)
+	}
+	<-notified
+	require.Equal(t, int32(1), calls.Load())
+	require.Equal(t, []Suggest{{Text: "sel"}}, c.GetSuggestions())
+}
+
+func TestCompletionManagerUpdateAsyncLoading(t *testing.T) {
+	release := make(chan struct{})
+	c := NewCompletionManager(nil, 6)
+	c.tmp = []Suggest{{Text: "stale"}}
+	c.completer = func(ctx context.Context, d Document) []Suggest {
+		<-release
+		return []Suggest{{Text: d.Text}}
+	}
+
+	notified := make(chan struct{}, 2)
+	c.UpdateAsync(context.Background(), Document{Text: "slow"}, func() { notified <- struct{}{} })
+	<-notified
+	require.True(t, c.Loading())
+	require.Empty(t, c.GetSuggestions())
+
+	close(release)
+	<-notified
+	require.False(t, c.Loading())
+	require.Equal(t, []Suggest{{Text: "slow"}}, c.GetSuggestions())
+}
+
+func TestCompletionManagerCancelUpdate(t *testing.T) {
+	c := NewCompletionManager(nil, 6)
+	c.completer = func(ctx context.Context, d Document) []Suggest {
+		<-ctx.Done()
+		return []Suggest{{Text: d.Text}}
+	}
+
+	c.UpdateAsync(context.Background(), Document{Text: "foo"}, func() {
+		t.Error("canceled update should not notify")
+	})
+	c.CancelUpdate()
+	time.Sleep(10 * time.Millisecond)
+	require.Empty(t, c.GetSuggestions())
+	require.False(t, c.Loading())
+}
@@ -0,0 +1,255 @@
+package prompt
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultEscapeTimeout is how long the prompt waits for the rest of an incomplete sequence, e.g. after ESC,
+// unless OptionEscapeTimeout is used. ESC is the Escape key when nothing follows it in time.
+const DefaultEscapeTimeout = 50 * time.Millisecond
+
+var (
+	// sequencesMu guards ASCIISequences and the registered keys, which may be changed while prompts are running.
+	sequencesMu     sync.RWMutex
+	sequencesSorted bool // whether ASCIISequences is sorted from the longest sequence, see RemoveASCIISequences
+	keyNames        = map[string]Key{}
+	nextKey         = lastKey + 1
+)
+
+// RegisterASCIISequence makes GetKey and the input decoder return key for the sequence,
+// e.g. for a sequence sent by a terminal which isn't known yet. It may be called while prompts are running.
+func RegisterASCIISequence(key Key, sequence []byte) {
+	sequencesMu.Lock()
+	defer sequencesMu.Unlock()
+
+	for _, s := range ASCIISequences {
+		if bytes.Equal(s.ASCIICode, sequence) {
+			s.Key = key
+			return
+		}
+	}
+	ASCIISequences = append(ASCIISequences, &ASCIICode{Key: key, ASCIICode: append([]byte{}, sequence...)})
+	if sequencesSorted {
+		sortASCIISequences()
+	}
+}
+
+// RegisterKey returns a new key named name, which is decoded from the sequence and can be bound with KeyBind.
+// Registering a name again adds the sequence to the same key.
+func RegisterKey(name string, sequence []byte) Key {
+	sequencesMu.Lock()
+	key, ok := keyNames[name]
+	if !ok {
+		key = nextKey
+		nextKey++
+		keyNames[name] = key
+	}
+	sequencesMu.Unlock()
+
+	RegisterASCIISequence(key, sequence)
+	return key
+}
+
+// KeyByName returns the key named name, either a key of this package like "ControlA" or a key added by RegisterKey.
+func KeyByName(name string) (Key, bool) {
+	for k := Escape; k <= lastKey; k++ {
+		if k.String() == name {
+			return k, true
+		}
+	}
+	sequencesMu.RLock()
+	defer sequencesMu.RUnlock()
+	key, ok := keyNames[name]
+	return key, ok
+}
+
+// sortASCIISequences sorts the sequences from the longest, sequencesMu must be locked.
+func sortASCIISequences() {
+	sort.SliceStable(ASCIISequences, func(i, j int) bool {
+		return len(ASCIISequences[i].ASCIICode) > len(ASCIISequences[j].ASCIICode)
+	})
+	sequencesSorted = true
+}
+
+// keyDecoder splits the bytes read from the terminal into the chunks fed to the prompt one by one.
+// A chunk is a known sequence, an unknown escape sequence, a whole bracketed paste, a Meta key or a run of text.
+// Several keys read at once are split, and a sequence or a UTF-8 character split between two reads is joined.
+type keyDecoder struct {
+	buf   []byte   // bytes which are not decoded yet
+	extra [][]byte // sequences of the ASCIICodeBindings, which aren't in ASCIISequences
+	// convertMeta decodes a byte which isn't UTF-8 as a Meta key, see OptionConvertMeta. It's text otherwise.
+	convertMeta bool
+}
+
+func newKeyDecoder(bindings []ASCIICodeBind) *keyDecoder {
+	d := &keyDecoder{}
+	for _, b := range bindings {
+		d.extra = append(d.extra, b.ASCIICode)
+	}
+	return d
+}
+
+// decode returns the chunks which are complete after b. An incomplete sequence or character at the end
+// is kept until the next call, or until flush.
+func (d *keyDecoder) decode(b []byte) [][]byte {
+	return d.run(b, false)
+}
+
+// flush returns the pending bytes decoded as they are, e.g. a pending ESC is the Escape key.
+// A paste is kept until its end marker is read.
+func (d *keyDecoder) flush() [][]byte {
+	return d.run(nil, true)
+}
+
+// pending returns whether some bytes are not decoded yet.
+func (d *keyDecoder) pending() bool {
+	return len(d.buf) > 0
+}
+
+func (d *keyDecoder) run(b []byte, flush bool) (chunks [][]byte) {
+	sequencesMu.RLock()
+	defer sequencesMu.RUnlock()
+
+	d.buf = append(d.buf, b...)
+	for len(d.buf) > 0 {
+		n := d.next(flush)
+		if n == 0 {
+			break
+		}
+		chunk := d.buf[:n:n]
+		if d.convertMeta && n == 1 && chunk[0] >= 0x80 {
+			// a byte which isn't UTF-8 is a Meta key sent with the eighth bit set, which is the same key as ESC and the character
+			chunk = []byte{0x1b, chunk[0] &^ 0x80}
+		}
+		chunks = append(chunks, chunk)
+		d.buf = d.buf[n:]
+	}
+	if len(d.buf) == 0 {
+		d.buf = nil
+	}
+	return chunks
+}
+
+// next returns the length of the chunk at the beginning of the buffer, 0 when it's incomplete.
+func (d *keyDecoder) next(flush bool) int {
+	buf := d.buf
+	// a paste is fed at once, however long it takes to read it
+	if bytes.HasPrefix(buf, pasteStart) {
+		if i := bytes.Index(buf[len(pasteStart):], pasteEnd); i >= 0 {
+			return len(pasteStart) + i + len(pasteEnd)
+		}
+		return 0
+	}
+
+	escape, complete := escapeSequenceLength(buf)
+	if !flush && (!complete || d.isIncomplete(buf)) {
+		return 0
+	}
+	if n := d.longestSequence(buf); n > 0 && n >= escape {
+		return n
+	}
+	if escape > 0 {
+		return escape
+	}
+	return d.textLength(buf, flush)
+}
+
+// sequences calls fn for each known sequence until it returns false.
+func (d *keyDecoder) sequences(fn func(s []byte) bool) {
+	for _, s := range ASCIISequences {
+		if !fn(s.ASCIICode) {
+			return
+		}
+	}
+	for _, s := range d.extra {
+		if !fn(s) {
+			return
+		}
+	}
+}
+
+// longestSequence returns the length of the longest known sequence at the beginning of b.
+func (d *keyDecoder) longestSequence(b []byte) (n int) {
+	d.sequences(func(s []byte) bool {
+		if len(s) > n && bytes.HasPrefix(b, s) {
+			n = len(s)
+		}
+		return true
+	})
+	return n
+}
+
+// isIncomplete returns whether b is the beginning of a longer known sequence or of the paste start marker.
+func (d *keyDecoder) isIncomplete(b []byte) (incomplete bool) {
+	if len(b) < len(pasteStart) && bytes.HasPrefix(pasteStart, b) {
+		return true
+	}
+	d.sequences(func(s []byte) bool {
+		incomplete = len(s) > len(b) && bytes.HasPrefix(s, b)
+		return !incomplete
+	})
+	return incomplete
+}
+
+// textLength returns the length of the text before the next sequence, without an incomplete character at the end.
+func (d *keyDecoder) textLength(b []byte, flush bool) int {
+	i := 0
+	for i < len(b) {
+		if i > 0 && (b[i] == 0x1b || d.longestSequence(b[i:]) > 0 || d.isIncomplete(b[i:])) {
+			break
+		}
+		if !flush && !utf8.FullRune(b[i:]) {
+			break
+		}
+		r, size := utf8.DecodeRune(b[i:])
+		if d.convertMeta && r == utf8.RuneError && size == 1 {
+			// a Meta key, see run
+			if i == 0 {
+				return 1
+			}
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// escapeSequenceLength returns the length of the CSI or SS3 sequence at the beginning of b, 0 when there is none.
+// complete is false when b ends before the end of the sequence.
+func escapeSequenceLength(b []byte) (n int, complete bool) {
+	if len(b) < 2 || b[0] != 0x1b {
+		return 0, true
+	}
+	switch b[1] {
+	case 'O':
+		if len(b) < 3 {
+			return 0, false
+		}
+		return 3, true
+	case '[':
+		i := 2
+		for i < len(b) && b[i] >= 0x30 && b[i] <= 0x3f { // parameter bytes
+			i++
+		}
+		for i < len(b) && b[i] >= 0x20 && b[i] <= 0x2f { // intermediate bytes
+			i++
+		}
+		if i == len(b) {
+			return 0, false
+		}
+		if b[i] >= 0x40 && b[i] <= 0x7e { // final byte
+			return i + 1, true
+		}
+	}
+	return 0, true
+}
+
+// isEscapeSequence returns whether b is a whole CSI or SS3 sequence.
+func isEscapeSequence(b []byte) bool {
+	n, _ := escapeSequenceLength(b)
+	return n > 0 && n == len(b)
+}
@@ -0,0 +1,172 @@
+package prompt
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyDecoder(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		reads       []string
+		flush       bool
+		convertMeta bool
+		want        []string
+		pending     bool
+	}{
+		{name: "text", reads: []string{"select"}, want: []string{"select"}},
+		{name: "keys read at once", reads: []string{"ab\x1b[Acd\r\x03"}, want: []string{"ab", "\x1b[A", "cd", "\r", "\x03"}},
+		{name: "sequence split between reads", reads: []string{"\x1b", "[", "1;5A"}, want: []string{"\x1b[1;5A"}},
+		{name: "incomplete sequence", reads: []string{"a\x1b["}, want: []string{"a"}, pending: true},
+		{name: "lone escape waits", reads: []string{"\x1b"}, pending: true},
+		{name: "lone escape is flushed", reads: []string{"\x1b"}, flush: true, want: []string{"\x1b"}},
+		{name: "incomplete sequence is flushed", reads: []string{"\x1b[1;"}, flush: true, want: []string{"\x1b", "[1;"}},
+		{name: "escape followed by an alt sequence", reads: []string{"\x1by"}, want: []string{"\x1by"}},
+		{name: "escape followed by text", reads: []string{"\x1bq"}, want: []string{"\x1b", "q"}},
+		{name: "escape followed by a key", reads: []string{"\x1b\x1b[B"}, want: []string{"\x1b", "\x1b[B"}},
+		{name: "unknown escape sequence", reads: []string{"\x1b[99;9~x"}, want: []string{"\x1b[99;9~", "x"}},
+		{name: "ss3 sequence", reads: []string{"\x1bOPx"}, want: []string{"\x1bOP", "x"}},
+		{name: "utf-8 character split between reads", reads: []string{"a\xe3\x81", "\x82b"}, want: []string{"a", "あb"}},
+		{name: "incomplete utf-8 character", reads: []string{"\xe3\x81"}, pending: true},
+		{name: "invalid utf-8", reads: []string{"a\xffb"}, want: []string{"a\xffb"}},
+		{name: "8-bit meta key", reads: []string{"a\xe2c"}, convertMeta: true, want: []string{"a", "\x1bb", "c"}},
+		{name: "8-bit meta key waits for the rest of a character", reads: []string{"\xe2"}, convertMeta: true, pending: true},
+		{name: "8-bit meta key is flushed", reads: []string{"\xe2"}, flush: true, convertMeta: true, want: []string{"\x1bb"}},
+		{
+			name:  "paste in a single read",
+			reads: []string{"\x1b[200~select 1;\r\x1b[201~"},
+			want:  []string{"\x1b[200~select 1;\r\x1b[201~"},
+		},
+		{
+			name:  "input around the paste",
+			reads: []string{"a\x1b[200~b\x1b[201~c"},
+			want:  []string{"a", "\x1b[200~b\x1b[201~", "c"},
+		},
+		{
+			name:  "paste spanning several reads",
+			reads: []string{"\x1b[200~select", " 1;\r", "select 2;\x1b[201~"},
+			want:  []string{"\x1b[200~select 1;\rselect 2;\x1b[201~"},
+		},
+		{
+			name:  "markers split between reads",
+			reads: []string{"\x1b[20", "0~a\x1b[2", "01~b"},
+			want:  []string{"\x1b[200~a\x1b[201~", "b"},
+		},
+		{
+			name:  "two pastes",
+			reads: []string{"\x1b[200~a\x1b[201~\x1b[200~b\x1b[201~"},
+			want:  []string{"\x1b[200~a\x1b[201~", "\x1b[200~b\x1b[201~"},
+		},
+		{name: "incomplete paste is not flushed", reads: []string{"\x1b[200~a"}, flush: true, pending: true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			d := newKeyDecoder(nil)
+			d.convertMeta = s.convertMeta
+			var chunks []string
+			for _, b := range s.reads {
+				for _, c := range d.decode([]byte(b)) {
+					chunks = append(chunks, string(c))
+				}
+			}
+			if s.flush {
+				for _, c := range d.flush() {
+					chunks = append(chunks, string(c))
+				}
+			}
+			require.Equal(t, s.want, chunks)
+			require.Equal(t, s.pending, d.pending())
+		})
+	}
+}
+
+func TestKeyDecoderASCIICodeBindings(t *testing.T) {
+	d := newKeyDecoder([]ASCIICodeBind{{ASCIICode: []byte("\x1bb")}, {ASCIICode: []byte("jk")}})
+	var chunks []string
+	for _, c := range d.decode([]byte("a\x1bbcjkj")) {
+		chunks = append(chunks, string(c))
+	}
+	require.Equal(t, []string{"a", "\x1bb", "c", "jk"}, chunks)
+	// j may be the beginning of jk
+	require.True(t, d.pending())
+}
+
+func TestRegisterKey(t *testing.T) {
+	key := RegisterKey("ControlAltX", []byte("\x1b\x18"))
+	require.Greater(t, key, lastKey)
+	require.Equal(t, key, RegisterKey("ControlAltX", []byte("\x1b[27;7;120~")))
+	require.Equal(t, key, GetKey([]byte("\x1b\x18")))
+	require.Equal(t, key, GetKey([]byte("\x1b[27;7;120~")))
+
+	named, ok := KeyByName("ControlAltX")
+	require.True(t, ok)
+	require.Equal(t, key, named)
+	named, ok = KeyByName("ControlA")
+	require.True(t, ok)
+	require.Equal(t, ControlA, named)
+	named, ok = KeyByName("AltBackspace")
+	require.True(t, ok)
+	require.Equal(t, AltBackspace, named)
+	_, ok = KeyByName("Unknown")
+	require.False(t, ok)
+
+	chunks := newKeyDecoder(nil).decode([]byte("\x1b\x18a"))
+	require.Equal(t, [][]byte{[]byte("\x1b\x18"), []byte("a")}, chunks)
+}
+
+func TestGetKeyUnknownEscapeSequence(t *testing.T) {
+	require.Equal(t, Ignore, GetKey([]byte("\x1b[99;9~")))
+	require.Equal(t, NotDefined, GetKey([]byte("[99;9~")))
+}
+
+func TestInputSplitIntoKeys(t *testing.T) {
+	scenarios := []struct {
+		name  string
+		input string
+		later string // written after the escape timeout
+		opts  []Option
+		want  string
+	}{
+		{name: "emacs", input: "select\x1b[Dx\r", want: "selecxt"},
+		{name: "vi", input: "ab\x1bx\r", opts: []Option{OptionSwitchKeyBindMode(ViKeyBind)}, want: "a"},
+		{name: "vi escape read with a key", input: "abc def\x1bbx\r", opts: []Option{OptionSwitchKeyBindMode(ViKeyBind)}, want: "abc ef"},
+		{name: "8-bit meta key", input: "abc def\xe2x\r", opts: []Option{OptionConvertMeta()}, want: "abc xdef"},
+		{name: "8-bit text", input: "abc\xe2x\r", want: "abc\xe2x"},
+		{name: "lone escape", input: "ab\x1b", later: "x\r", opts: []Option{OptionSwitchKeyBindMode(ViKeyBind)}, want: "a"},
+		{
+			name:  "no escape timeout",
+			input: "ab\x1b",
+			later: "x\r",
+			opts:  []Option{OptionSwitchKeyBindMode(ViKeyBind), OptionEscapeTimeout(0)},
+			want:  "a",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			file, err := os.CreateTemp(t.TempDir(), "input")
+			require.NoError(t, err)
+			t.Cleanup(func() { file.Close() })
+			t.Setenv(EnvVarInputFile, file.Name())
+			_, err = file.WriteString(s.input)
+			require.NoError(t, err)
+
+			p, err := New(nil, nil, append(s.opts, OptionWriter(NoopWriter{}))...)
+			require.NoError(t, err)
+			if s.later != "" {
+				go func() {
+					time.Sleep(2 * DefaultEscapeTimeout)
+					_, _ = file.WriteString(s.later)
+				}()
+			}
+			text, err := p.InputContext(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, s.want, text)
+		})
+	}
+}
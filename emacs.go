@@ -18,6 +18,8 @@ Moving the cursor
 * [x] Ctrl + f   Forward one character
 * [x] Ctrl + b   Backward one character
 * [x] Ctrl + xx  Toggle between the start of line and current cursor position
+* [x] Alt  + f   Forward one word
+* [x] Alt  + b   Backward one word
 
 Editing
 -------
@@ -29,12 +31,19 @@ Editing
 * [x] Ctrl + w   Cut the Word before the cursor to the clipboard.
 * [x] Ctrl + k   Cut the Line after the cursor to the clipboard.
 * [x] Ctrl + u   Cut/delete the Line before the cursor to the clipboard.
+* [x] Alt  + d   Cut the Word after the cursor to the clipboard.
+* [x] Alt  + Backspace  Cut the Word before the cursor to the clipboard.
+
+* [x] Alt  + u   Uppercase the Word after the cursor.
+* [x] Alt  + l   Lowercase the Word after the cursor.
+* [x] Alt  + c   Capitalize the Word after the cursor.
 
 * [ ] Ctrl + t   Swap the last two characters before the cursor (typo).
-* [ ] Esc  + t   Swap the last two words before the cursor.
+* [x] Esc  + t   Swap the word before the cursor with the word after it.
 
-* [ ] ctrl + y   Paste the last thing to be cut (yank)
-* [ ] ctrl + _   Undo
+* [x] ctrl + y   Paste the last thing to be cut (yank)
+* [x] Esc  + y   Replace the yanked text with the previous thing cut (yank-pop)
+* [x] ctrl + _   Undo
 
 */
 
@@ -60,7 +69,7 @@ var emacsKeyBindings = []KeyBind{
 		Key: ControlK,
 		Fn: func(buf *Buffer) {
 			x := []rune(buf.Document().TextAfterCursor())
-			buf.Delete(len(x))
+			buf.Kill(len(x))
 		},
 	},
 	// Cut/delete the Line before the cursor
@@ -68,9 +77,70 @@ var emacsKeyBindings = []KeyBind{
 		Key: ControlU,
 		Fn: func(buf *Buffer) {
 			x := []rune(buf.Document().TextBeforeCursor())
-			buf.DeleteBeforeCursor(len(x))
+			buf.KillBeforeCursor(len(x))
+		},
+	},
+	// Paste the last thing to be cut
+	{
+		Key: ControlY,
+		Fn: func(buf *Buffer) {
+			buf.Yank()
 		},
 	},
+	// Replace the yanked text with the previous thing cut
+	{
+		Key: AltY,
+		Fn: func(buf *Buffer) {
+			buf.YankPop()
+		},
+	},
+	// Undo
+	{
+		Key: ControlUnderscore,
+		Fn: func(buf *Buffer) {
+			buf.Undo()
+		},
+	},
+	// Swap the word before the cursor with the word after it
+	{
+		Key: AltT,
+		Fn:  TransposeWords,
+	},
+	// Forward one word
+	{
+		Key: AltF,
+		Fn:  GoRightWord,
+	},
+	// Backward one word
+	{
+		Key: AltB,
+		Fn:  GoLeftWord,
+	},
+	// Cut the Word after the cursor
+	{
+		Key: AltD,
+		Fn:  KillWord,
+	},
+	// Cut the Word before the cursor
+	{
+		Key: AltBackspace,
+		Fn:  BackwardKillWord,
+	},
+	// Uppercase the Word after the cursor
+	{
+		Key: AltU,
+		Fn:  UpcaseWord,
+	},
+	// Lowercase the Word after the cursor
+	{
+		Key: AltL,
+		Fn:  DowncaseWord,
+	},
+	// Capitalize the Word after the cursor
+	{
+		Key: AltC,
+		Fn:  CapitalizeWord,
+	},
 	// Delete character under the cursor
 	{
 		Key: ControlD,
@@ -105,7 +175,7 @@ var emacsKeyBindings = []KeyBind{
 	{
 		Key: ControlW,
 		Fn: func(buf *Buffer) {
-			buf.DeleteBeforeCursor(len([]rune(buf.Document().GetWordBeforeCursorWithSpace())))
+			buf.KillBeforeCursor(len([]rune(buf.Document().GetWordBeforeCursorWithSpace())))
 		},
 	},
 	// Clear the Screen, similar to the clear command
@@ -1,6 +1,10 @@
 package prompt
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
 
 func TestEmacsKeyBindings(t *testing.T) {
 	buf := NewBuffer()
@@ -30,3 +34,71 @@ func applyEmacsKeyBind(buf *Buffer, key Key) {
 		}
 	}
 }
+
+func TestEmacsKillRing(t *testing.T) {
+	p := newTestPrompt(EmacsKeyBind)
+	p.KillRing().Push("seed")
+
+	for _, b := range [][]byte{
+		[]byte("foo bar"),
+		{0x17},       // Ctrl+W
+		{0x1},        // Ctrl+A
+		{0xb},        // Ctrl+K
+		{0x19},       // Ctrl+Y
+		{0x1b, 0x79}, // Alt+Y
+	} {
+		_, err := p.feed(b)
+		require.NoError(t, err)
+	}
+	require.Equal(t, "bar", p.buf.Text())
+	require.Equal(t, []string{"foo ", "bar", "seed"}, p.KillRing().Entries())
+
+	_, err := p.feed([]byte{0x1b, 0x79})
+	require.NoError(t, err)
+	require.Equal(t, "seed", p.buf.Text())
+}
+
+func TestEmacsWordKeyBindings(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		keys        []string
+		convertMeta bool
+		text        string
+		cursor      int
+		killed      []string
+	}{
+		{name: "alt+b", keys: []string{"\x1bb"}, text: "select foo  from", cursor: 12},
+		{name: "alt+b twice", keys: []string{"\x1bb", "\x1bb"}, text: "select foo  from", cursor: 7},
+		{name: "8-bit alt+b", keys: []string{"\xe2"}, convertMeta: true, text: "select foo  from", cursor: 12},
+		{name: "alt+f", keys: []string{"\x01", "\x1bf"}, text: "select foo  from", cursor: 6},
+		{name: "ctrl+left", keys: []string{"\x1b[1;5D"}, text: "select foo  from", cursor: 12},
+		{name: "ctrl+right", keys: []string{"\x01", "\x1b[1;5C", "\x1b[1;5C"}, text: "select foo  from", cursor: 10},
+		{name: "alt+d", keys: []string{"\x01", "\x1bd", "\x1bd"}, text: "  from", killed: []string{"select foo"}},
+		{name: "alt+backspace", keys: []string{"\x1b\x7f", "\x1b\x7f"}, text: "select ", cursor: 7, killed: []string{"foo  from"}},
+		{name: "alt+u", keys: []string{"\x01", "\x1bu"}, text: "SELECT foo  from", cursor: 6},
+		{name: "alt+l", keys: []string{"\x01", "\x1bu", "\x01", "\x1bl"}, text: "select foo  from", cursor: 6},
+		{name: "alt+c", keys: []string{"\x01", "\x1bf", "\x1bc", "\x1bc"}, text: "select Foo  From", cursor: 16},
+		{name: "alt+t", keys: []string{"\x01", "\x1bf", "\x1bt"}, text: "foo select  from", cursor: 10},
+		{name: "alt+t at the end", keys: []string{"\x1bt"}, text: "select from  foo", cursor: 16},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			p := newTestPrompt(EmacsKeyBind)
+			p.killRing = NewKillRing(DefaultKillRingSize)
+			p.buf.InsertText("select foo  from", false, true)
+
+			for _, k := range s.keys {
+				d := newKeyDecoder(nil)
+				d.convertMeta = s.convertMeta
+				for _, b := range d.run([]byte(k), true) {
+					_, err := p.feed(b)
+					require.NoError(t, err)
+				}
+			}
+			require.Equal(t, s.text, p.buf.Text())
+			require.Equal(t, s.cursor, p.buf.Document().cursorPosition)
+			require.Equal(t, append([]string{}, s.killed...), p.KillRing().Entries())
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrEOF is returned when the user sends EOF (Ctrl+D) on an empty buffer.
+	ErrEOF = errors.New("prompt: EOF")
+	// ErrInterrupted is returned when the prompt is stopped by a termination signal (SIGINT, SIGTERM or SIGQUIT).
+	// See InterruptedError to know which signal was caught.
+	ErrInterrupted = errors.New("prompt: interrupted")
+	// ErrCanceled is returned when the context passed to RunContext or InputContext is done.
+	// The returned error also wraps the context error, so errors.Is(err, context.DeadlineExceeded) works as well.
+	ErrCanceled = errors.New("prompt: canceled")
+	// ErrExited is returned by InputContext when the ExitChecker stopped the prompt before any input was accepted.
+	ErrExited = errors.New("prompt: exited")
+	// ErrReadCanceled is returned by CancelableConsoleParser.Read when the read is canceled.
+	ErrReadCanceled = errors.New("prompt: read canceled")
+)
+
+// InterruptedError is returned when the prompt is stopped by a signal.
+// It matches ErrInterrupted when using errors.Is.
+type InterruptedError struct {
+	Signal os.Signal
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("%s by signal: %s", ErrInterrupted, e.Signal)
+}
+
+// Is reports whether target is ErrInterrupted.
+func (e *InterruptedError) Is(target error) bool {
+	return target == ErrInterrupted
+}
+
+func canceledError(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", ErrCanceled, ctx.Err())
+}
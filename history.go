@@ -1,16 +1,110 @@
 package prompt
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/go-prompt/internal/debug"
+)
+
 // History stores the texts that are entered.
+// The entries are appended to a HistoryStore as well, see SetStore.
 type History struct {
-	histories []string
-	tmp       []string
-	selected  int
+	histories      []string
+	tmp            []string
+	selected       int
+	store          HistoryStore
+	sessionID      string
+	maxFileEntries int
 }
 
 // Add to add text in history.
 func (h *History) Add(input string) {
-	h.histories = append(h.histories, input)
+	h.AddEntry(HistoryEntry{Text: input})
+}
+
+// AddEntry adds the entry to the history and appends it to the store.
+// The time and the session ID of the history are set when they are empty.
+func (h *History) AddEntry(entry HistoryEntry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.SessionID == "" {
+		entry.SessionID = h.SessionID()
+	}
+	h.histories = append(h.histories, entry.Text)
 	h.Clear()
+	if h.store != nil {
+		if err := h.store.Append(entry); err != nil {
+			debug.Log("failed to append to the history store: " + err.Error())
+		}
+	}
+}
+
+// SetStore adds the entries of the store to the history, and appends the entries added later to it.
+func (h *History) SetStore(store HistoryStore) error {
+	var entries []string
+	if err := store.Iterate(func(e HistoryEntry) bool {
+		entries = append(entries, e.Text)
+		return true
+	}); err != nil {
+		return err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		h.histories = append(h.histories, entries[i])
+	}
+	h.store = store
+	h.Clear()
+	return nil
+}
+
+// Store returns the store set by SetStore, it's a MemoryHistoryStore by default.
+func (h *History) Store() HistoryStore {
+	if h.store == nil {
+		h.store = NewMemoryHistoryStore()
+	}
+	return h.store
+}
+
+// Search returns at most limit entries of the store containing query with their metadata, the newest first.
+func (h *History) Search(query string, limit int) ([]HistoryEntry, error) {
+	return h.Store().Search(query, limit)
+}
+
+// SessionID returns the ID recorded with the entries added by this history. It's random unless SetSessionID is used.
+func (h *History) SessionID() string {
+	if h.sessionID == "" {
+		id := make([]byte, 8)
+		_, _ = rand.Read(id)
+		h.sessionID = hex.EncodeToString(id)
+	}
+	return h.sessionID
+}
+
+// SetSessionID sets the ID recorded with the entries added later.
+func (h *History) SetSessionID(id string) {
+	h.sessionID = id
+}
+
+// LoadFile adds the entries stored in the file at path to the history, and appends the entries added later to it.
+// A missing file is created by the first entry. See FileHistoryStore.
+func (h *History) LoadFile(path string) error {
+	store, err := NewFileHistoryStore(path, h.maxFileEntries)
+	if err != nil {
+		return err
+	}
+	return h.SetStore(store)
+}
+
+// SetMaxFileEntries sets the number of entries kept in the history file, or in the MemoryHistoryStore without a file.
+// The oldest entries are removed when it's exceeded. DefaultHistoryFileMaxEntries is used when max is not positive.
+func (h *History) SetMaxFileEntries(max int) {
+	h.maxFileEntries = max
+	if store, ok := h.store.(interface{ SetMaxEntries(int) }); ok {
+		store.SetMaxEntries(max)
+	}
 }
 
 // Clear to clear the history.
@@ -23,6 +117,7 @@ func (h *History) Clear() {
 
 // Older saves a buffer of current line and get a buffer of previous line by up-arrow.
 // The changes of line buffers are stored until new history is created.
+// The cursor is put at the end of the first line of a multi-line entry, so that up-arrow goes to the previous entry again.
 func (h *History) Older(buf *Buffer) (new *Buffer, changed bool) {
 	if len(h.tmp) == 1 || h.selected == 0 {
 		return buf, false
@@ -30,9 +125,7 @@ func (h *History) Older(buf *Buffer) (new *Buffer, changed bool) {
 	h.tmp[h.selected] = buf.Text()
 
 	h.selected--
-	new = NewBuffer()
-	new.InsertText(h.tmp[h.selected], false, true)
-	return new, true
+	return newHistoryBuffer(h.tmp[h.selected], true), true
 }
 
 func (h *History) HasNewer() bool {
@@ -41,6 +134,7 @@ func (h *History) HasNewer() bool {
 
 // Newer saves a buffer of current line and get a buffer of next line by up-arrow.
 // The changes of line buffers are stored until new history is created.
+// The cursor is put at the end of the last line, so that down-arrow goes to the next entry again.
 func (h *History) Newer(buf *Buffer) (new *Buffer, changed bool) {
 	if h.selected >= len(h.tmp)-1 {
 		return buf, false
@@ -48,9 +142,50 @@ func (h *History) Newer(buf *Buffer) (new *Buffer, changed bool) {
 	h.tmp[h.selected] = buf.Text()
 
 	h.selected++
-	new = NewBuffer()
-	new.InsertText(h.tmp[h.selected], false, true)
-	return new, true
+	return newHistoryBuffer(h.tmp[h.selected], false), true
+}
+
+// newHistoryBuffer returns a buffer with the text of an entry and the cursor at the end of its first or last line.
+func newHistoryBuffer(text string, firstLine bool) *Buffer {
+	buf := NewBuffer()
+	buf.InsertText(text, false, true)
+	if i := strings.IndexByte(text, '\n'); firstLine && i >= 0 {
+		buf.setCursorPosition(len([]rune(text[:i])))
+	}
+	return buf
+}
+
+// OlderWithPrefix is like Older, but skips the entries which don't start with prefix or are the same as the current line.
+// The cursor is put after the prefix, so that the next call searches the same prefix.
+func (h *History) OlderWithPrefix(buf *Buffer, prefix string) (new *Buffer, changed bool) {
+	for i := h.selected - 1; i >= 0; i-- {
+		if strings.HasPrefix(h.tmp[i], prefix) && h.tmp[i] != buf.Text() {
+			return h.selectWithPrefix(buf, i, prefix), true
+		}
+	}
+	return buf, false
+}
+
+// NewerWithPrefix is like Newer, but skips the entries which don't start with prefix or are the same as the current line.
+// The line being edited before going through the history is never skipped.
+func (h *History) NewerWithPrefix(buf *Buffer, prefix string) (new *Buffer, changed bool) {
+	for i := h.selected + 1; i < len(h.tmp); i++ {
+		if i == len(h.tmp)-1 || strings.HasPrefix(h.tmp[i], prefix) && h.tmp[i] != buf.Text() {
+			return h.selectWithPrefix(buf, i, prefix), true
+		}
+	}
+	return buf, false
+}
+
+func (h *History) selectWithPrefix(buf *Buffer, i int, prefix string) *Buffer {
+	h.tmp[h.selected] = buf.Text()
+	h.selected = i
+	new := NewBuffer()
+	new.InsertText(h.tmp[i], false, true)
+	if strings.HasPrefix(h.tmp[i], prefix) {
+		new.setCursorPosition(len([]rune(prefix)))
+	}
+	return new
 }
 
 // NewHistory returns new history object.
@@ -59,5 +194,6 @@ func NewHistory() *History {
 		histories: []string{},
 		tmp:       []string{""},
 		selected:  0,
+		store:     NewMemoryHistoryStore(),
 	}
 }
@@ -0,0 +1,217 @@
+package prompt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultHistoryFileMaxEntries is the number of entries kept in a history file unless OptionHistoryFileMaxEntries is used.
+const DefaultHistoryFileMaxEntries = 1000
+
+// FileHistoryStore is a HistoryStore persisting the entries in a file with one JSON object per line.
+// A lock file next to it serializes the access of several processes sharing the file.
+type FileHistoryStore struct {
+	path string
+
+	mu         sync.Mutex
+	maxEntries int
+	entries    []HistoryEntry // oldest first, the newest entries loaded from the file and the entries appended since then
+	// fileEntries is the number of entries in the file, those read and those appended since then by this store.
+	fileEntries int
+}
+
+// NewFileHistoryStore loads the newest maxEntries entries of the file at path, or DefaultHistoryFileMaxEntries
+// when maxEntries is not positive. The file is created by the first appended entry.
+// The appends are cheap: the file is only rewritten with the newest entries once it has twice as many.
+func NewFileHistoryStore(path string, maxEntries int) (*FileHistoryStore, error) {
+	s := &FileHistoryStore{path: path}
+	s.SetMaxEntries(maxEntries)
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.fileEntries = len(entries)
+	s.entries = entries[max(len(entries)-s.maxEntries, 0):]
+	return s, nil
+}
+
+// SetMaxEntries sets the number of entries kept in the file, the oldest entries are removed when it's exceeded.
+// DefaultHistoryFileMaxEntries is used when max is not positive.
+func (s *FileHistoryStore) SetMaxEntries(max int) {
+	if max <= 0 {
+		max = DefaultHistoryFileMaxEntries
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxEntries = max
+}
+
+// Append adds the entry at the end of the file with a single write, and compacts the file when it has twice
+// as many entries as kept.
+func (s *FileHistoryStore) Append(entry HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(entry)
+}
+
+// Iterate calls fn for each entry, the newest first, until fn returns false.
+// The entries appended by other processes after the store was created are not included.
+func (s *FileHistoryStore) Iterate(fn func(entry HistoryEntry) bool) error {
+	iterateHistoryEntries(s.snapshot(), fn)
+	return nil
+}
+
+// Search returns at most limit entries containing query, the newest first.
+func (s *FileHistoryStore) Search(query string, limit int) ([]HistoryEntry, error) {
+	return searchHistoryEntries(s.snapshot(), query, limit), nil
+}
+
+// Len returns the number of entries.
+func (s *FileHistoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *FileHistoryStore) snapshot() []HistoryEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries[:len(s.entries):len(s.entries)]
+}
+
+// load returns the entries stored in the file, the file doesn't have to exist.
+func (s *FileHistoryStore) load() ([]HistoryEntry, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.read()
+}
+
+// append writes the entry and adds it to the entries once it's in the file, even if the compaction fails.
+func (s *FileHistoryStore) append(entry HistoryEntry) error {
+	var line bytes.Buffer
+	enc := json.NewEncoder(&line)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(entry); err != nil {
+		return err
+	}
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(line.Bytes())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	s.entries = append(s.entries, entry)
+	if len(s.entries) > s.maxEntries {
+		s.entries = s.entries[len(s.entries)-s.maxEntries:]
+	}
+	s.fileEntries++
+	if s.fileEntries <= 2*s.maxEntries {
+		return nil
+	}
+	return s.compact()
+}
+
+// compact keeps the newest maxEntries entries of the file. The exclusive lock must be held.
+func (s *FileHistoryStore) compact() error {
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	s.fileEntries = len(entries)
+	if len(entries) <= s.maxEntries {
+		return nil
+	}
+	entries = entries[len(entries)-s.maxEntries:]
+
+	// write a temporary file and rename it, so that the file is never truncated if the process dies
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		_ = enc.Encode(e)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	s.fileEntries = len(entries)
+	return nil
+}
+
+func (s *FileHistoryStore) read() ([]HistoryEntry, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// the lines are read whole whatever their length, a line which isn't an entry, e.g. cut by a crash, is skipped
+	var entries []HistoryEntry
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		var entry HistoryEntry
+		if len(bytes.TrimSpace(line)) > 0 && json.Unmarshal(line, &entry) == nil {
+			entries = append(entries, entry)
+		}
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// lock locks the lock file next to the history file. The lock is shared unless exclusive is true.
+// The history file itself isn't locked, because compact replaces it.
+func (s *FileHistoryStore) lock(exclusive bool) (unlock func(), err error) {
+	file, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file, exclusive); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unlockFile(file)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,149 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h := NewHistory()
+	require.NoError(t, h.LoadFile(path))
+	require.Empty(t, h.histories)
+	h.Add("select 1")
+	h.Add("select *\nfrom t")
+	h.Add("select *\r\n\nfrom t\n")
+
+	// another session sees the entries of the first one with the line breaks kept
+	other := NewHistory()
+	require.NoError(t, other.LoadFile(path))
+	require.Equal(t, []string{"select 1", "select *\nfrom t", "select *\r\n\nfrom t\n"}, other.histories)
+	other.Add("show tables")
+
+	again := NewHistory()
+	require.NoError(t, again.LoadFile(path))
+	require.Equal(t, []string{"select 1", "select *\nfrom t", "select *\r\n\nfrom t\n", "show tables"}, again.histories)
+}
+
+func TestHistoryFileMaxEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h := NewHistory()
+	h.SetMaxFileEntries(3)
+	require.NoError(t, h.LoadFile(path))
+	for i := 0; i < 6; i++ {
+		h.Add(fmt.Sprintf("select %d", i))
+	}
+	// the file isn't compacted until it has twice as many entries as kept
+	store, err := NewFileHistoryStore(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, 6, store.Len())
+	store, err = NewFileHistoryStore(path, 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"select 5", "select 4", "select 3"}, historyTexts(t, store))
+
+	h.Add("select 6")
+	store, err = NewFileHistoryStore(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"select 6", "select 5", "select 4"}, historyTexts(t, store))
+
+	// no temporary file is left behind
+	files, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, files, 2) // the history file and the lock file
+}
+
+func TestHistoryFileConcurrentSessions(t *testing.T) {
+	const sessions, entries = 8, 25
+
+	for _, maxEntries := range []int{sessions * entries, 50} {
+		t.Run(fmt.Sprintf("max entries %d", maxEntries), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "history")
+
+			var wg sync.WaitGroup
+			for s := 0; s < sessions; s++ {
+				wg.Add(1)
+				go func(s int) {
+					defer wg.Done()
+					store, err := NewFileHistoryStore(path, maxEntries)
+					require.NoError(t, err)
+					for i := 0; i < entries; i++ {
+						require.NoError(t, store.Append(HistoryEntry{Text: fmt.Sprintf("session %d\nentry %d", s, i)}))
+					}
+				}(s)
+			}
+			wg.Wait()
+
+			store, err := NewFileHistoryStore(path, maxEntries)
+			require.NoError(t, err)
+			loaded := historyTexts(t, store)
+			require.Len(t, loaded, maxEntries)
+			for _, e := range loaded {
+				require.Regexp(t, `^session \d+\nentry \d+$`, e)
+			}
+
+			// the next entry compacts the file if it has more than twice as many entries as kept
+			require.NoError(t, store.Append(HistoryEntry{Text: "show tables"}))
+			store, err = NewFileHistoryStore(path, 0)
+			require.NoError(t, err)
+			require.LessOrEqual(t, store.Len(), 2*maxEntries)
+		})
+	}
+}
+
+func TestFileHistoryStoreMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	entry := HistoryEntry{
+		Text:      "select *\nfrom t where a < 1",
+		Time:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Duration:  1500 * time.Millisecond,
+		Failed:    true,
+		SessionID: "session",
+	}
+
+	store, err := NewFileHistoryStore(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, store.Append(entry))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `{"text":"select *\nfrom t where a < 1","time":"2024-05-01T12:00:00Z","duration":1500000000,"failed":true,"session_id":"session"}`+"\n", string(b))
+
+	loaded, err := NewFileHistoryStore(path, 0)
+	require.NoError(t, err)
+	found, err := loaded.Search("a < 1", 0)
+	require.NoError(t, err)
+	require.Equal(t, []HistoryEntry{entry}, found)
+}
+
+func TestFileHistoryStoreInvalidLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte(`{"text":"select 1"}`+"\nselect 2\n\n"+`{"text":"select 3"`), 0600))
+
+	store, err := NewFileHistoryStore(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"select 1"}, historyTexts(t, store))
+}
+
+func TestFileHistoryStoreLongEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	long := strings.Repeat("select 1;\n", 200*1024)
+
+	store, err := NewFileHistoryStore(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, store.Append(HistoryEntry{Text: long}))
+	require.NoError(t, store.Append(HistoryEntry{Text: "show tables"}))
+
+	again, err := NewFileHistoryStore(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"show tables", long}, historyTexts(t, again))
+	require.Equal(t, historyTexts(t, store), historyTexts(t, again))
+}
@@ -0,0 +1,203 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// historySearch is the state of the incremental history search started by Ctrl+R or Ctrl+S.
+type historySearch struct {
+	active bool
+	// buffer before the search, it's restored when the search is aborted.
+	original *Buffer
+	// one step per typed character and per repeated Ctrl+R or Ctrl+S, Backspace goes back to the previous step.
+	steps []searchStep
+	// query of the previous search, Ctrl+R and Ctrl+S search it again when nothing was typed yet.
+	lastQuery []rune
+}
+
+type searchStep struct {
+	query   []rune
+	reverse bool
+	failed  bool
+	index   int // index of the matched history entry, the number of entries for the original buffer
+	pos     int // position of the match in the entry
+}
+
+// searchMatch is the part of the buffer matched by the history search, highlighted by the renderer.
+type searchMatch struct {
+	prefix string
+	row    int
+	col    int
+	length int
+}
+
+func (m *searchMatch) contains(row, col int) bool {
+	return m != nil && row == m.row && col >= m.col && col < m.col+m.length
+}
+
+// startHistorySearch starts the incremental history search like Ctrl+R and Ctrl+S of bash.
+func (p *Prompt) startHistorySearch(reverse bool) {
+	p.search.active = true
+	p.search.original = p.buf
+	p.search.steps = []searchStep{{reverse: reverse, index: len(p.history.histories)}}
+	p.showHistorySearch()
+}
+
+// handleHistorySearch handles the key while searching the history. It returns false when the key ended the search
+// and has to be handled as usual, e.g. Enter accepts the matched entry and arrow keys move in it like bash.
+func (p *Prompt) handleHistorySearch(key Key, b []byte) bool {
+	step := p.search.steps[len(p.search.steps)-1]
+	switch key {
+	case ControlR, ControlS:
+		next := step
+		next.reverse = key == ControlR
+		if len(next.query) == 0 {
+			next.query = p.search.lastQuery
+		}
+		if len(next.query) > 0 {
+			p.search.steps = append(p.search.steps, p.findHistory(next, len(step.query) > 0))
+		}
+	case Backspace, ControlH:
+		if len(p.search.steps) > 1 {
+			p.search.steps = p.search.steps[:len(p.search.steps)-1]
+		}
+	case ControlG:
+		p.buf = p.search.original
+		p.endHistorySearch()
+		return true
+	case NotDefined:
+		next := step
+		next.query = append(append([]rune{}, step.query...), []rune(string(RemoveASCIISequences(b)))...)
+		if !step.failed {
+			next = p.findHistory(next, false)
+		}
+		p.search.steps = append(p.search.steps, next)
+	case ControlC:
+		p.buf = p.search.original
+		p.endHistorySearch()
+		return false
+	default:
+		p.endHistorySearch()
+		return false
+	}
+	p.showHistorySearch()
+	return true
+}
+
+// findHistory returns the step matching the query of step, starting at the match of step.
+// The match of step is skipped when skipCurrent is true, e.g. to find the next match with Ctrl+R.
+func (p *Prompt) findHistory(step searchStep, skipCurrent bool) searchStep {
+	entries := p.history.histories
+	index, pos := step.index, step.pos
+	for index >= 0 && index <= len(entries) {
+		if index < len(entries) {
+			text := []rune(entries[index])
+			found := -1
+			if step.reverse {
+				found = lastIndexRunes(text, step.query, pos, skipCurrent)
+			} else {
+				found = indexRunes(text, step.query, pos, skipCurrent)
+			}
+			if found >= 0 {
+				step.index, step.pos, step.failed = index, found, false
+				return step
+			}
+		}
+
+		// continue with the whole next entry
+		skipCurrent = false
+		if step.reverse {
+			index--
+			if index >= 0 {
+				pos = len([]rune(entries[index]))
+			}
+		} else {
+			index++
+			pos = 0
+		}
+	}
+	step.failed = true
+	return step
+}
+
+// lastIndexRunes returns the last position of query in text starting at or before pos, or before pos when skipPos is true.
+func lastIndexRunes(text, query []rune, pos int, skipPos bool) int {
+	if skipPos {
+		pos--
+	}
+	for i := min(pos, len(text)-len(query)); i >= 0; i-- {
+		if hasRunesAt(text, query, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexRunes returns the first position of query in text starting at or after pos, or after pos when skipPos is true.
+func indexRunes(text, query []rune, pos int, skipPos bool) int {
+	if skipPos {
+		pos++
+	}
+	for i := max(pos, 0); i <= len(text)-len(query); i++ {
+		if hasRunesAt(text, query, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func hasRunesAt(text, query []rune, pos int) bool {
+	if pos+len(query) > len(text) {
+		return false
+	}
+	for i, r := range query {
+		if text[pos+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// showHistorySearch puts the matched entry into the buffer and tells the renderer to show the search prompt.
+func (p *Prompt) showHistorySearch() {
+	step := p.search.steps[len(p.search.steps)-1]
+	name := "i-search"
+	if step.reverse {
+		name = "reverse-i-search"
+	}
+	if step.failed {
+		name = "failed " + name
+	}
+	match := &searchMatch{prefix: fmt.Sprintf("(%s)`%s': ", name, string(step.query))}
+
+	if step.index == len(p.history.histories) {
+		p.buf = p.search.original
+	} else {
+		text := []rune(p.history.histories[step.index])
+		p.buf = NewBuffer()
+		p.buf.InsertText(string(text), false, false)
+		p.buf.cursorPosition = step.pos
+
+		before := string(text[:step.pos])
+		match.row = strings.Count(before, "\n")
+		match.col = len([]rune(before[strings.LastIndex(before, "\n")+1:]))
+		if !step.failed {
+			match.length = len(step.query)
+		}
+	}
+	p.renderer.search = match
+	p.renderer.hideCompletion = true
+}
+
+// endHistorySearch stops the search and keeps the buffer as it is.
+func (p *Prompt) endHistorySearch() {
+	if step := p.search.steps[len(p.search.steps)-1]; len(step.query) > 0 {
+		p.search.lastQuery = step.query
+	}
+	p.search.active = false
+	p.search.original = nil
+	p.search.steps = nil
+	p.renderer.search = nil
+	p.renderer.hideCompletion = false
+}
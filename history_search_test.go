@@ -0,0 +1,143 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistorySearch(t *testing.T) {
+	const (
+		ctrlR = "\x12"
+		ctrlS = "\x13"
+		ctrlG = "\x07"
+		bs    = "\x7f"
+	)
+	histories := []string{"select 1", "show tables", "select *\nfrom orders", "describe orders"}
+
+	scenarios := []struct {
+		name   string
+		keys   []string
+		text   string
+		cursor int
+		prefix string
+		match  *searchMatch
+		active bool
+	}{
+		{
+			name:   "start",
+			keys:   []string{ctrlR},
+			text:   "typed",
+			cursor: 5,
+			prefix: "(reverse-i-search)`': ",
+			active: true,
+		},
+		{
+			name:   "newest match",
+			keys:   []string{ctrlR, "o", "r"},
+			text:   "describe orders",
+			cursor: 9,
+			prefix: "(reverse-i-search)`or': ",
+			active: true,
+		},
+		{
+			name:   "older match in a multi-line entry",
+			keys:   []string{ctrlR, "o", "r", ctrlR},
+			text:   "select *\nfrom orders",
+			cursor: 14,
+			prefix: "(reverse-i-search)`or': ",
+			match:  &searchMatch{row: 1, col: 5, length: 2},
+			active: true,
+		},
+		{
+			name:   "failed search keeps the last match",
+			keys:   []string{ctrlR, "o", "r", "x"},
+			text:   "describe orders",
+			cursor: 9,
+			prefix: "(failed reverse-i-search)`orx': ",
+			active: true,
+		},
+		{
+			name:   "backspace goes back to the previous step",
+			keys:   []string{ctrlR, "o", "r", ctrlR, bs},
+			text:   "describe orders",
+			cursor: 9,
+			prefix: "(reverse-i-search)`or': ",
+			active: true,
+		},
+		{
+			name:   "forward search",
+			keys:   []string{ctrlR, "s", "e", "l", ctrlR, ctrlS},
+			text:   "select *\nfrom orders",
+			cursor: 0,
+			prefix: "(i-search)`sel': ",
+			active: true,
+		},
+		{
+			name:   "abort restores the buffer",
+			keys:   []string{ctrlR, "s", "h", ctrlG},
+			text:   "typed",
+			cursor: 5,
+		},
+		{
+			name:   "other keys end the search and are handled as usual",
+			keys:   []string{ctrlR, "s", "h", "\x05"}, // Ctrl+E
+			text:   "show tables",
+			cursor: 11,
+		},
+		{
+			name:   "repeat the previous search",
+			keys:   []string{ctrlR, "t", "a", "b", ctrlG, ctrlR, ctrlR},
+			text:   "show tables",
+			cursor: 5,
+			prefix: "(reverse-i-search)`tab': ",
+			active: true,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			p := newTestPrompt(EmacsKeyBind)
+			for _, h := range histories {
+				p.history.Add(h)
+			}
+			p.buf.InsertText("typed", false, true)
+
+			for _, k := range s.keys {
+				_, err := p.feed([]byte(k))
+				require.NoError(t, err)
+			}
+			require.Equal(t, s.text, p.buf.Text())
+			require.Equal(t, s.cursor, p.buf.cursorPosition)
+			require.Equal(t, s.active, p.search.active)
+			if !s.active {
+				require.Nil(t, p.renderer.search)
+				return
+			}
+			require.Equal(t, s.prefix, p.renderer.getCurrentPrefix())
+			if s.match != nil {
+				require.Equal(t, s.match.row, p.renderer.search.row)
+				require.Equal(t, s.match.col, p.renderer.search.col)
+				require.Equal(t, s.match.length, p.renderer.search.length)
+			}
+		})
+	}
+}
+
+func TestHistorySearchAccept(t *testing.T) {
+	p := newTestPrompt(EmacsKeyBind)
+	p.renderer = &Render{out: NoopWriter{}, col: 80}
+	p.lexer = NewLexer()
+	p.statementTerminatorCb = func(Key, *Buffer) bool { return true }
+	p.renderer.livePrefixCallback = func() (string, bool) { return "", false }
+	p.history.Add("show tables")
+
+	for _, k := range []string{"\x12", "t", "a"} {
+		_, err := p.feed([]byte(k))
+		require.NoError(t, err)
+	}
+	exec, err := p.feed([]byte{0xd})
+	require.NoError(t, err)
+	require.Equal(t, "show tables", exec.input)
+	require.False(t, p.search.active)
+}
@@ -0,0 +1,120 @@
+package prompt
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryEntry is an accepted input with the metadata recorded by the prompt.
+type HistoryEntry struct {
+	Text string `json:"text"`
+	// Time is when the input was accepted.
+	Time time.Time `json:"time,omitempty"`
+	// Duration is how long the executor ran, it's zero for Input.
+	Duration time.Duration `json:"duration,omitempty"`
+	// Failed is true when the executor set by OptionExecutorWithError returned an error.
+	Failed bool `json:"failed,omitempty"`
+	// SessionID identifies the prompt which added the entry, see OptionHistorySessionID.
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// HistoryStore stores the history entries, see OptionHistoryStore.
+// The methods may be called from any goroutine.
+type HistoryStore interface {
+	// Append adds the entry as the newest one.
+	Append(entry HistoryEntry) error
+	// Iterate calls fn for each entry, the newest first, until fn returns false.
+	Iterate(fn func(entry HistoryEntry) bool) error
+	// Search returns at most limit entries containing query, the newest first. There is no limit when limit is not positive.
+	Search(query string, limit int) ([]HistoryEntry, error)
+	// Len returns the number of entries.
+	Len() int
+}
+
+// MemoryHistoryStore is a HistoryStore keeping the newest DefaultHistoryFileMaxEntries entries in memory,
+// unless SetMaxEntries is used.
+type MemoryHistoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    []HistoryEntry // oldest first
+}
+
+// NewMemoryHistoryStore returns a store with the entries, the oldest first.
+func NewMemoryHistoryStore(entries ...HistoryEntry) *MemoryHistoryStore {
+	return &MemoryHistoryStore{maxEntries: DefaultHistoryFileMaxEntries, entries: entries}
+}
+
+// SetMaxEntries sets the number of entries kept, the oldest entries are removed when it's exceeded.
+// DefaultHistoryFileMaxEntries is used when max is not positive.
+func (s *MemoryHistoryStore) SetMaxEntries(max int) {
+	if max <= 0 {
+		max = DefaultHistoryFileMaxEntries
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxEntries = max
+	s.truncate()
+}
+
+// Append adds the entry as the newest one.
+func (s *MemoryHistoryStore) Append(entry HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	s.truncate()
+	return nil
+}
+
+// truncate removes the oldest entries exceeding maxEntries. The lock must be held.
+func (s *MemoryHistoryStore) truncate() {
+	if s.maxEntries > 0 && len(s.entries) > s.maxEntries {
+		// copy, so that the removed entries are released and the snapshots taken before are kept
+		s.entries = append([]HistoryEntry(nil), s.entries[len(s.entries)-s.maxEntries:]...)
+	}
+}
+
+// Iterate calls fn for each entry, the newest first, until fn returns false.
+func (s *MemoryHistoryStore) Iterate(fn func(entry HistoryEntry) bool) error {
+	iterateHistoryEntries(s.snapshot(), fn)
+	return nil
+}
+
+// Search returns at most limit entries containing query, the newest first.
+func (s *MemoryHistoryStore) Search(query string, limit int) ([]HistoryEntry, error) {
+	return searchHistoryEntries(s.snapshot(), query, limit), nil
+}
+
+// Len returns the number of entries.
+func (s *MemoryHistoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// snapshot returns the entries, so that fn can be called without holding the lock.
+func (s *MemoryHistoryStore) snapshot() []HistoryEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries[:len(s.entries):len(s.entries)]
+}
+
+// iterateHistoryEntries calls fn for the entries stored oldest first, the newest first.
+func iterateHistoryEntries(entries []HistoryEntry, fn func(entry HistoryEntry) bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !fn(entries[i]) {
+			return
+		}
+	}
+}
+
+func searchHistoryEntries(entries []HistoryEntry, query string, limit int) []HistoryEntry {
+	var found []HistoryEntry
+	iterateHistoryEntries(entries, func(e HistoryEntry) bool {
+		if strings.Contains(e.Text, query) {
+			found = append(found, e)
+		}
+		return limit <= 0 || len(found) < limit
+	})
+	return found
+}
@@ -0,0 +1,125 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// historyTexts returns the texts of the entries of the store, the newest first.
+func historyTexts(t *testing.T, store HistoryStore) []string {
+	var texts []string
+	require.NoError(t, store.Iterate(func(e HistoryEntry) bool {
+		texts = append(texts, e.Text)
+		return true
+	}))
+	return texts
+}
+
+func TestMemoryHistoryStore(t *testing.T) {
+	store := NewMemoryHistoryStore(HistoryEntry{Text: "select 1"}, HistoryEntry{Text: "show tables"})
+	require.NoError(t, store.Append(HistoryEntry{Text: "select 2"}))
+	require.Equal(t, 3, store.Len())
+	require.Equal(t, []string{"select 2", "show tables", "select 1"}, historyTexts(t, store))
+
+	var first []string
+	require.NoError(t, store.Iterate(func(e HistoryEntry) bool {
+		first = append(first, e.Text)
+		return false
+	}))
+	require.Equal(t, []string{"select 2"}, first)
+
+	scenarios := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{query: "select", want: []string{"select 2", "select 1"}},
+		{query: "select", limit: 1, want: []string{"select 2"}},
+		{query: "", limit: 2, want: []string{"select 2", "show tables"}},
+		{query: "describe"},
+	}
+	for _, s := range scenarios {
+		found, err := store.Search(s.query, s.limit)
+		require.NoError(t, err)
+		var texts []string
+		for _, e := range found {
+			texts = append(texts, e.Text)
+		}
+		require.Equal(t, s.want, texts)
+	}
+}
+
+func TestMemoryHistoryStoreMaxEntries(t *testing.T) {
+	store := NewMemoryHistoryStore()
+	for i := 0; i < DefaultHistoryFileMaxEntries+5; i++ {
+		require.NoError(t, store.Append(HistoryEntry{Text: fmt.Sprintf("select %d", i)}))
+	}
+	require.Equal(t, DefaultHistoryFileMaxEntries, store.Len())
+
+	h := NewHistory()
+	h.SetMaxFileEntries(2)
+	for _, text := range []string{"select 1", "select 2", "select 3"} {
+		h.Add(text)
+	}
+	require.Equal(t, []string{"select 3", "select 2"}, historyTexts(t, h.Store()))
+}
+
+func TestHistoryStore(t *testing.T) {
+	store := NewMemoryHistoryStore(HistoryEntry{Text: "select 1"}, HistoryEntry{Text: "select 2"})
+	h := NewHistory()
+	h.Add("show tables")
+	require.NoError(t, h.SetStore(store))
+	require.Equal(t, []string{"show tables", "select 1", "select 2"}, h.histories)
+
+	h.SetSessionID("session")
+	h.AddEntry(HistoryEntry{Text: "select 3", Duration: time.Second, Failed: true})
+	require.Equal(t, []string{"show tables", "select 1", "select 2", "select 3"}, h.histories)
+
+	found, err := h.Search("select 3", 0)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, time.Second, found[0].Duration)
+	require.True(t, found[0].Failed)
+	require.Equal(t, "session", found[0].SessionID)
+	require.False(t, found[0].Time.IsZero())
+}
+
+func TestPromptRecordsHistoryMetadata(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "input")
+	require.NoError(t, err)
+	t.Cleanup(func() { file.Close() })
+	t.Setenv(EnvVarInputFile, file.Name())
+
+	store := NewMemoryHistoryStore()
+	p, err := New(nil, nil,
+		OptionWriter(NoopWriter{}),
+		OptionHistoryStore(store),
+		OptionHistorySessionID("session"),
+		OptionExecutorWithError(func(in string) error {
+			time.Sleep(10 * time.Millisecond)
+			return errors.New("failed")
+		}),
+		OptionSetExitCheckerOnInput(func(in string, breakline bool) bool { return breakline }),
+	)
+	require.NoError(t, err)
+
+	go func() {
+		p.SetText("select 1")
+		p.Accept()
+	}()
+	require.NoError(t, p.RunContext(context.Background()))
+
+	found, err := store.Search("", 0)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "select 1", found[0].Text)
+	require.True(t, found[0].Failed)
+	require.GreaterOrEqual(t, found[0].Duration, 10*time.Millisecond)
+	require.Equal(t, "session", found[0].SessionID)
+}
@@ -13,6 +13,8 @@ func TestHistoryClear(t *testing.T) {
 		histories: []string{"foo"},
 		tmp:       []string{"foo", ""},
 		selected:  1,
+		store:     h.store,
+		sessionID: h.sessionID,
 	}
 	if !reflect.DeepEqual(expected, h) {
 		t.Errorf("Should be %#v, but got %#v", expected, h)
@@ -26,6 +28,8 @@ func TestHistoryAdd(t *testing.T) {
 		histories: []string{"echo 1"},
 		tmp:       []string{"echo 1", ""},
 		selected:  1,
+		store:     h.store,
+		sessionID: h.sessionID,
 	}
 	if !reflect.DeepEqual(h, expected) {
 		t.Errorf("Should be %v, but got %v", expected, h)
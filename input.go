@@ -2,7 +2,6 @@ package prompt
 
 import (
 	"bytes"
-	"sort"
 	"sync"
 )
 
@@ -26,24 +25,53 @@ type ConsoleParser interface {
 	Read() ([]byte, error)
 }
 
+// CancelableConsoleParser is a ConsoleParser whose Read blocks until input is available.
+// The prompt doesn't need to poll it, so that an idle prompt doesn't consume CPU.
+type CancelableConsoleParser interface {
+	ConsoleParser
+	// Cancel wakes up a pending Read, which returns ErrReadCanceled.
+	// If no Read is pending, the next one returns immediately.
+	Cancel() error
+}
+
 // GetKey returns Key correspond to input byte codes.
+// A whole paste surrounded by the markers of the bracketed paste mode is BracketedPaste,
+// and an escape sequence which isn't known is Ignore.
 func GetKey(b []byte) Key {
+	if bytes.HasPrefix(b, pasteStart) && bytes.HasSuffix(b, pasteEnd) {
+		return BracketedPaste
+	}
+	sequencesMu.RLock()
+	defer sequencesMu.RUnlock()
 	for _, k := range ASCIISequences {
 		if bytes.Equal(k.ASCIICode, b) {
 			return k.Key
 		}
 	}
+	if isMouseSequence(b) {
+		return Vt100MouseEvent
+	}
+	if isEscapeSequence(b) {
+		return Ignore
+	}
 	return NotDefined
 }
 
+// isMetaKey returns whether b is a Meta key, i.e. ESC followed by a character, e.g. AltB.
+func isMetaKey(b []byte) bool {
+	return len(b) == 2 && b[0] == 0x1b && b[1] >= 0x20 && b[1] != '[' && b[1] != 'O'
+}
+
 // RemoveASCIISequences sanitizes the input bytes of ascii sequences that mess with the rendering
 func RemoveASCIISequences(input []byte) []byte {
 	once.Do(func() {
 		//go from longest to shortest sequence to avoid having subsequence issues
-		sort.Slice(ASCIISequences, func(i, j int) bool {
-			return len(ASCIISequences[i].ASCIICode) > len(ASCIISequences[j].ASCIICode)
-		})
+		sequencesMu.Lock()
+		sortASCIISequences()
+		sequencesMu.Unlock()
 	})
+	sequencesMu.RLock()
+	defer sequencesMu.RUnlock()
 	for _, specialSequence := range ASCIISequences {
 		// skip \n and \r because those are valid input
 		if specialSequence.Key == Enter || specialSequence.Key == ControlM {
@@ -93,6 +121,16 @@ var ASCIISequences = []*ASCIICode{
 	{Key: Backspace, ASCIICode: []byte{0x7f}},
 
 	{Key: AltEnter, ASCIICode: []byte{0x1b, 0xd}},
+	{Key: AltY, ASCIICode: []byte{0x1b, 0x79}},
+	{Key: AltT, ASCIICode: []byte{0x1b, 0x74}},
+	{Key: AltF, ASCIICode: []byte{0x1b, 0x66}},
+	{Key: AltB, ASCIICode: []byte{0x1b, 0x62}},
+	{Key: AltD, ASCIICode: []byte{0x1b, 0x64}},
+	{Key: AltC, ASCIICode: []byte{0x1b, 0x63}},
+	{Key: AltU, ASCIICode: []byte{0x1b, 0x75}},
+	{Key: AltL, ASCIICode: []byte{0x1b, 0x6c}},
+	{Key: AltBackspace, ASCIICode: []byte{0x1b, 0x7f}},
+	{Key: AltBackspace, ASCIICode: []byte{0x1b, 0x8}},
 
 	{Key: Up, ASCIICode: []byte{0x1b, 0x5b, 0x41}},
 	{Key: Down, ASCIICode: []byte{0x1b, 0x5b, 0x42}},
@@ -0,0 +1,26 @@
+//go:build !windows && !darwin
+
+package prompt
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// waitForInput blocks until fd or cancelFd is readable.
+// It reports whether the wait was canceled by writing to cancelFd.
+func waitForInput(fd, cancelFd int) (canceled bool, err error) {
+	fds := []unix.PollFd{
+		{Fd: int32(fd), Events: unix.POLLIN},
+		{Fd: int32(cancelFd), Events: unix.POLLIN},
+	}
+	for {
+		_, err = unix.Poll(fds, -1)
+		if err != unix.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return fds[1].Revents&unix.POLLIN != 0, nil
+}
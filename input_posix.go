@@ -3,6 +3,7 @@
 package prompt
 
 import (
+	"errors"
 	"syscall"
 
 	"github.com/confluentinc/go-prompt/internal/term"
@@ -12,16 +13,20 @@ import (
 const maxReadBytes = 1024
 
 // PosixParser is a ConsoleParser implementation for POSIX environment.
+// Read blocks until the terminal is readable, a self-pipe is used to wake it up on Cancel.
 type PosixParser struct {
-	fd int
+	fd      int
+	cancelR int
+	cancelW int
 }
 
 // Setup should be called before starting input
 func (t *PosixParser) Setup() error {
-	// Set NonBlocking mode because if syscall.Read block this goroutine, it cannot receive data from stopCh.
+	// Set NonBlocking mode so that a spurious wake up of waitForInput never blocks the read.
 	if err := syscall.SetNonblock(t.fd, true); err != nil {
 		return err
 	}
+	t.drainCancel()
 	if err := term.SetRaw(t.fd); err != nil {
 		return err
 	}
@@ -39,8 +44,18 @@ func (t *PosixParser) TearDown() error {
 	return nil
 }
 
-// Read returns byte array.
+// Read blocks until the terminal is readable and returns byte array.
+// It returns ErrReadCanceled when Cancel is called.
 func (t *PosixParser) Read() ([]byte, error) {
+	canceled, err := waitForInput(t.fd, t.cancelR)
+	if err != nil {
+		return []byte{}, err
+	}
+	if canceled {
+		t.drainCancel()
+		return []byte{}, ErrReadCanceled
+	}
+
 	buf := make([]byte, maxReadBytes)
 	n, err := syscall.Read(t.fd, buf)
 	if err != nil {
@@ -49,6 +64,30 @@ func (t *PosixParser) Read() ([]byte, error) {
 	return buf[:n], nil
 }
 
+// Cancel wakes up a pending Read. If no Read is pending, the next one returns immediately.
+func (t *PosixParser) Cancel() error {
+	_, err := syscall.Write(t.cancelW, []byte{0})
+	if err == syscall.EAGAIN {
+		// the pipe is full, so Read is woken up anyway
+		return nil
+	}
+	return err
+}
+
+func (t *PosixParser) drainCancel() {
+	buf := make([]byte, 64)
+	for {
+		if n, err := syscall.Read(t.cancelR, buf); err != nil || n < len(buf) {
+			return
+		}
+	}
+}
+
+// Close closes the terminal and the self-pipe. The parser can't be used afterwards.
+func (t *PosixParser) Close() error {
+	return errors.Join(syscall.Close(t.cancelR), syscall.Close(t.cancelW), syscall.Close(t.fd))
+}
+
 // GetWinSize returns WinSize object to represent width and height of terminal.
 func (t *PosixParser) GetWinSize() *WinSize {
 	ws, err := unix.IoctlGetWinsize(t.fd, unix.TIOCGWINSZ)
@@ -61,7 +100,7 @@ func (t *PosixParser) GetWinSize() *WinSize {
 	}
 }
 
-var _ ConsoleParser = &PosixParser{}
+var _ CancelableConsoleParser = &PosixParser{}
 
 // NewStandardInputParser returns ConsoleParser object to read from stdin.
 func NewStandardInputParser() (*PosixParser, error) {
@@ -69,8 +108,31 @@ func NewStandardInputParser() (*PosixParser, error) {
 	if err != nil {
 		return nil, err
 	}
+	parser, err := newPosixParser(in)
+	if err != nil {
+		syscall.Close(in)
+		return nil, err
+	}
+	return parser, nil
+}
+
+func newPosixParser(fd int) (*PosixParser, error) {
+	p := make([]int, 2)
+	if err := unix.Pipe(p); err != nil {
+		return nil, err
+	}
+	for _, pipeFd := range p {
+		unix.CloseOnExec(pipeFd)
+		if err := unix.SetNonblock(pipeFd, true); err != nil {
+			unix.Close(p[0])
+			unix.Close(p[1])
+			return nil, err
+		}
+	}
 
 	return &PosixParser{
-		fd: in,
+		fd:      fd,
+		cancelR: p[0],
+		cancelW: p[1],
 	}, nil
 }
@@ -0,0 +1,184 @@
+//go:build !windows
+
+package prompt
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newPipeParser(t testing.TB) (*PosixParser, *os.File) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+
+	// the parser closes its own descriptor of the pipe
+	fd, err := syscall.Dup(int(r.Fd()))
+	require.NoError(t, err)
+	p, err := newPosixParser(fd)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, p.Close()) })
+	return p, w
+}
+
+func TestPosixParserClose(t *testing.T) {
+	openFds := func() int {
+		entries, err := os.ReadDir("/dev/fd")
+		require.NoError(t, err)
+		return len(entries)
+	}
+	before := openFds()
+	for i := 0; i < 10; i++ {
+		r, err := os.Open(os.DevNull)
+		require.NoError(t, err)
+		fd, err := syscall.Dup(int(r.Fd()))
+		require.NoError(t, err)
+		r.Close()
+		p, err := newPosixParser(fd)
+		require.NoError(t, err)
+		require.NoError(t, p.Close())
+	}
+	require.Equal(t, before, openFds())
+}
+
+func TestNewWithParserOpensNoTerminal(t *testing.T) {
+	t.Setenv(EnvVarInputFile, "")
+	openFds := func() int {
+		entries, err := os.ReadDir("/dev/fd")
+		require.NoError(t, err)
+		return len(entries)
+	}
+	in, _ := newPipeParser(t)
+	before := openFds()
+	_, err := New(nil, nil, OptionParser(pipeParser{in}), OptionWriter(NoopWriter{}))
+	require.NoError(t, err)
+	require.Equal(t, before, openFds())
+}
+
+func TestPosixParserRead(t *testing.T) {
+	p, w := newPipeParser(t)
+
+	_, err := w.Write([]byte("abc"))
+	require.NoError(t, err)
+
+	b, err := p.Read()
+	require.NoError(t, err)
+	require.Equal(t, []byte("abc"), b)
+}
+
+func TestPosixParserCancel(t *testing.T) {
+	p, _ := newPipeParser(t)
+
+	errCh := make(chan error)
+	go func() {
+		_, err := p.Read()
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		t.Fatalf("Read should block until input is available, but returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, p.Cancel())
+	select {
+	case err := <-errCh:
+		require.ErrorIs(t, err, ErrReadCanceled)
+	case <-time.After(time.Second):
+		t.Fatal("Read should return after Cancel")
+	}
+
+	// Cancel before Read makes the next Read return immediately, but only once.
+	require.NoError(t, p.Cancel())
+	require.NoError(t, p.Cancel())
+	_, err := p.Read()
+	require.ErrorIs(t, err, ErrReadCanceled)
+	p.drainCancel()
+}
+
+func TestReadBufferEscapeTimeout(t *testing.T) {
+	in, w := newPipeParser(t)
+	p := &Prompt{in: in, escapeTimeout: DefaultEscapeTimeout}
+	bufCh := make(chan []byte, 1)
+	defer p.startReadBuffer(bufCh)()
+
+	_, err := w.Write([]byte("\x1b"))
+	require.NoError(t, err)
+	select {
+	case b := <-bufCh:
+		t.Fatalf("ESC should wait for the rest of a sequence, but got %q", b)
+	case <-time.After(DefaultEscapeTimeout / 2):
+	}
+	select {
+	case b := <-bufCh:
+		require.Equal(t, []byte{0x1b}, b)
+	case <-time.After(time.Second):
+		t.Fatal("ESC should be decoded after the timeout")
+	}
+}
+
+// pipeParser reads from a pipe instead of a terminal.
+type pipeParser struct {
+	*PosixParser
+}
+
+func (p pipeParser) Setup() error { return nil }
+
+func (p pipeParser) TearDown() error { return nil }
+
+func (p pipeParser) GetWinSize() *WinSize { return &WinSize{Row: 50, Col: 100} }
+
+// flushWriter notifies every flush, which happens once per render.
+type flushWriter struct {
+	NoopWriter
+	flushed chan struct{}
+}
+
+func (w flushWriter) Flush() error {
+	w.flushed <- struct{}{}
+	return nil
+}
+
+// BenchmarkKeyToRender measures the latency between a key press and the end of the render.
+func BenchmarkKeyToRender(b *testing.B) {
+	in, w := newPipeParser(b)
+	out := flushWriter{flushed: make(chan struct{}, 1)}
+	p, err := New(func(string) {}, nil, OptionParser(pipeParser{in}), OptionWriter(out))
+	require.NoError(b, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = p.RunContext(ctx)
+	}()
+	<-out.flushed // initial render
+
+	key := []byte{'a'}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := w.Write(key); err != nil {
+			b.Fatal(err)
+		}
+		<-out.flushed
+	}
+	b.StopTimer()
+
+	cancel()
+	for {
+		select {
+		case <-out.flushed:
+		case <-done:
+			return
+		}
+	}
+}
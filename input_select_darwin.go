@@ -0,0 +1,27 @@
+//go:build darwin
+
+package prompt
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// waitForInput blocks until fd or cancelFd is readable.
+// It reports whether the wait was canceled by writing to cancelFd.
+// poll(2) does not support devices on macOS, so select(2) is used instead.
+func waitForInput(fd, cancelFd int) (canceled bool, err error) {
+	var fds unix.FdSet
+	for {
+		fds.Zero()
+		fds.Set(fd)
+		fds.Set(cancelFd)
+		_, err = unix.Select(max(fd, cancelFd)+1, &fds, nil, nil, nil)
+		if err != unix.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return fds.IsSet(cancelFd), nil
+}
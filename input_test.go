@@ -56,6 +56,10 @@ func TestSanitizeInputWithASCIISequences(t *testing.T) {
 				if sequence.Key == Enter || sequence.Key == ControlM {
 					continue
 				}
+				// likewise an Escape ending up in front of a character of the text is the same sequence as e.g. AltT
+				if sequence.Key == Escape {
+					continue
+				}
 				inputString = append(inputString, sequence.ASCIICode...)
 			}
 		}
@@ -0,0 +1,230 @@
+// Package terminfo reads the entries of the compiled terminfo database, see term(5).
+package terminfo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	magic       = 0o432  // entries with 16-bit numbers
+	magicNumber = 0o1036 // entries with 32-bit numbers
+)
+
+// ErrNotFound is returned by Load when there is no entry for the terminal.
+var ErrNotFound = errors.New("terminfo: entry not found")
+
+// Terminfo is an entry of the terminfo database. The capabilities which are absent or canceled are not in the maps.
+type Terminfo struct {
+	Names   []string
+	Bools   map[string]bool
+	Numbers map[string]int
+	Strings map[string]string
+}
+
+// Load reads the entry of the terminal from the directories searched by ncurses:
+// $TERMINFO, ~/.terminfo, $TERMINFO_DIRS and the system directories.
+func Load(term string) (*Terminfo, error) {
+	if term == "" || strings.ContainsAny(term, "/\\") || strings.HasPrefix(term, ".") {
+		return nil, ErrNotFound
+	}
+	for _, dir := range searchPath() {
+		for _, sub := range []string{term[:1], fmt.Sprintf("%02x", term[0])} {
+			b, err := os.ReadFile(filepath.Join(dir, sub, term))
+			if err == nil {
+				return Parse(b)
+			}
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func searchPath() []string {
+	var dirs []string
+	if d := os.Getenv("TERMINFO"); d != "" {
+		dirs = append(dirs, d)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	system := []string{"/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo", "/usr/lib/terminfo"}
+	if d, ok := os.LookupEnv("TERMINFO_DIRS"); ok {
+		for _, p := range strings.Split(d, ":") {
+			if p == "" {
+				// an empty path stands for the system directories
+				dirs = append(dirs, system...)
+			} else {
+				dirs = append(dirs, p)
+			}
+		}
+		return dirs
+	}
+	return append(dirs, system...)
+}
+
+// reader reads the sections of a compiled entry.
+type reader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.b) {
+		r.err = errors.New("terminfo: truncated entry")
+		return nil
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) shorts(n int) []int {
+	b := r.bytes(2 * n)
+	if b == nil {
+		return nil
+	}
+	s := make([]int, n)
+	for i := range s {
+		s[i] = int(int16(binary.LittleEndian.Uint16(b[2*i:])))
+	}
+	return s
+}
+
+func (r *reader) numbers(n int, wide bool) []int {
+	if !wide {
+		return r.shorts(n)
+	}
+	b := r.bytes(4 * n)
+	if b == nil {
+		return nil
+	}
+	s := make([]int, n)
+	for i := range s {
+		s[i] = int(int32(binary.LittleEndian.Uint32(b[4*i:])))
+	}
+	return s
+}
+
+// align skips the padding byte which puts the next section at an even offset.
+func (r *reader) align() {
+	if r.pos%2 == 1 {
+		r.bytes(1)
+	}
+}
+
+// Parse parses a compiled entry, including the extended capabilities of ncurses.
+func Parse(b []byte) (*Terminfo, error) {
+	r := &reader{b: b}
+	header := r.shorts(6)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if header[0] != magic && header[0] != magicNumber {
+		return nil, errors.New("terminfo: bad magic number")
+	}
+	wide := header[0] == magicNumber
+	ti := &Terminfo{Bools: map[string]bool{}, Numbers: map[string]int{}, Strings: map[string]string{}}
+
+	names := r.bytes(header[1])
+	ti.Names = strings.Split(strings.TrimRight(string(names), "\x00"), "|")
+	bools := r.bytes(header[2])
+	r.align()
+	nums := r.numbers(header[3], wide)
+	offsets := r.shorts(header[4])
+	table := r.bytes(header[5])
+	if r.err != nil {
+		return nil, r.err
+	}
+	for i, v := range bools {
+		if v == 1 && i < len(boolNames) {
+			ti.Bools[boolNames[i]] = true
+		}
+	}
+	for i, v := range nums {
+		if v >= 0 && i < len(numNames) {
+			ti.Numbers[numNames[i]] = v
+		}
+	}
+	for i, off := range offsets {
+		if s, ok := tableString(table, off); ok && i < len(stringNames) {
+			ti.Strings[stringNames[i]] = s
+		}
+	}
+
+	r.align()
+	if r.pos < len(b) {
+		if err := parseExtended(r, ti, wide); err != nil {
+			return nil, err
+		}
+	}
+	return ti, nil
+}
+
+// parseExtended parses the section of the user-defined capabilities, e.g. Tc or RGB.
+func parseExtended(r *reader, ti *Terminfo, wide bool) error {
+	header := r.shorts(5)
+	if r.err != nil {
+		return r.err
+	}
+	nBools, nNums, nStrings, tableSize := header[0], header[1], header[2], header[4]
+	bools := r.bytes(nBools)
+	r.align()
+	nums := r.numbers(nNums, wide)
+	valueOffsets := r.shorts(nStrings)
+	nameOffsets := r.shorts(nBools + nNums + nStrings)
+	table := r.bytes(tableSize)
+	if r.err != nil {
+		return r.err
+	}
+
+	// the names follow the values of the strings in the table
+	namesStart := 0
+	for _, off := range valueOffsets {
+		if s, ok := tableString(table, off); ok {
+			namesStart = max(namesStart, off+len(s)+1)
+		}
+	}
+	name := func(i int) (string, bool) {
+		if namesStart > len(table) {
+			return "", false
+		}
+		return tableString(table[namesStart:], nameOffsets[i])
+	}
+	for i, v := range bools {
+		if n, ok := name(i); ok && v == 1 {
+			ti.Bools[n] = true
+		}
+	}
+	for i, v := range nums {
+		if n, ok := name(nBools + i); ok && v >= 0 {
+			ti.Numbers[n] = v
+		}
+	}
+	for i, off := range valueOffsets {
+		n, ok := name(nBools + nNums + i)
+		if s, found := tableString(table, off); ok && found {
+			ti.Strings[n] = s
+		}
+	}
+	return nil
+}
+
+// tableString returns the NUL terminated string at off in the table, false when it's absent or canceled.
+func tableString(table []byte, off int) (string, bool) {
+	if off < 0 || off >= len(table) {
+		return "", false
+	}
+	end := off
+	for end < len(table) && table[end] != 0 {
+		end++
+	}
+	return string(table[off:end]), true
+}
@@ -0,0 +1,198 @@
+package terminfo
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type extended struct {
+	bools   []string
+	numbers map[string]int
+	strings map[string]string
+}
+
+// compile returns the compiled entry of ti, with ext as the extended capabilities, in the format read by Parse.
+func compile(ti *Terminfo, ext *extended) []byte {
+	var b []byte
+	short := func(n int) { b = binary.LittleEndian.AppendUint16(b, uint16(int16(n))) }
+	align := func() {
+		if len(b)%2 == 1 {
+			b = append(b, 0)
+		}
+	}
+	names := ""
+	for i, n := range ti.Names {
+		if i > 0 {
+			names += "|"
+		}
+		names += n
+	}
+	var table []byte
+	for _, n := range []int{magic, len(names) + 1, len(boolNames), len(numNames), len(stringNames)} {
+		short(n)
+	}
+	offsets := make([]int, len(stringNames))
+	for i, name := range stringNames {
+		offsets[i] = -1
+		if s, ok := ti.Strings[name]; ok {
+			offsets[i] = len(table)
+			table = append(append(table, s...), 0)
+		}
+	}
+	short(len(table))
+	b = append(append(b, names...), 0)
+	for _, name := range boolNames {
+		if ti.Bools[name] {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+	}
+	align()
+	for _, name := range numNames {
+		if n, ok := ti.Numbers[name]; ok {
+			short(n)
+		} else {
+			short(-1)
+		}
+	}
+	for _, off := range offsets {
+		short(off)
+	}
+	b = append(b, table...)
+	if ext == nil {
+		return b
+	}
+
+	align()
+	var values, extNames []byte
+	var valueOffsets, nameOffsets []int
+	addName := func(n string) {
+		nameOffsets = append(nameOffsets, len(extNames))
+		extNames = append(append(extNames, n...), 0)
+	}
+	var extNums, extStrings []string
+	for n := range ext.numbers {
+		extNums = append(extNums, n)
+	}
+	for n := range ext.strings {
+		extStrings = append(extStrings, n)
+	}
+	for _, n := range ext.bools {
+		addName(n)
+	}
+	for _, n := range extNums {
+		addName(n)
+	}
+	for _, n := range extStrings {
+		addName(n)
+		valueOffsets = append(valueOffsets, len(values))
+		values = append(append(values, ext.strings[n]...), 0)
+	}
+	for _, n := range []int{len(ext.bools), len(extNums), len(extStrings), len(extStrings) + len(nameOffsets), len(values) + len(extNames)} {
+		short(n)
+	}
+	for range ext.bools {
+		b = append(b, 1)
+	}
+	align()
+	for _, n := range extNums {
+		short(ext.numbers[n])
+	}
+	for _, off := range valueOffsets {
+		short(off)
+	}
+	for _, off := range nameOffsets {
+		short(off)
+	}
+	return append(append(b, values...), extNames...)
+}
+
+func TestParse(t *testing.T) {
+	want := &Terminfo{
+		Names:   []string{"test", "Test terminal"},
+		Bools:   map[string]bool{"am": true, "xenl": true},
+		Numbers: map[string]int{"colors": 8, "cols": 80},
+		Strings: map[string]string{"cup": "\x1b[%i%p1%d;%p2%dH", "el": "\x1b[K", "sgr0": "\x1b[m\x0f"},
+	}
+	ti, err := Parse(compile(want, nil))
+	require.NoError(t, err)
+	require.Equal(t, want, ti)
+
+	ti, err = Parse(compile(want, &extended{
+		bools:   []string{"Tc"},
+		numbers: map[string]int{"U8": 1},
+		strings: map[string]string{"Ss": "\x1b[%p1%d q"},
+	}))
+	require.NoError(t, err)
+	require.True(t, ti.Bools["Tc"])
+	require.True(t, ti.Bools["am"])
+	require.Equal(t, 1, ti.Numbers["U8"])
+	require.Equal(t, "\x1b[%p1%d q", ti.Strings["Ss"])
+	require.Equal(t, "\x1b[K", ti.Strings["el"])
+
+	_, err = Parse([]byte{1, 2, 3})
+	require.Error(t, err)
+	_, err = Parse(make([]byte, 12))
+	require.Error(t, err)
+	b := compile(want, nil)
+	_, err = Parse(b[:len(b)-4])
+	require.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	entry := compile(&Terminfo{Names: []string{"test"}, Strings: map[string]string{"el": "\x1b[K"}}, nil)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "74"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "74", "test"), entry, 0o644))
+	t.Setenv("TERMINFO", dir)
+	t.Setenv("TERMINFO_DIRS", "")
+	t.Setenv("HOME", dir)
+
+	ti, err := Load("test")
+	require.NoError(t, err)
+	require.Equal(t, []string{"test"}, ti.Names)
+	require.Equal(t, "\x1b[K", ti.Strings["el"])
+
+	for _, term := range []string{"", "unknown-terminal", "../test", ".test"} {
+		_, err = Load(term)
+		require.ErrorIs(t, err, ErrNotFound, term)
+	}
+}
+
+func TestTparm(t *testing.T) {
+	scenarios := []struct {
+		cap    string
+		params []any
+		want   string
+	}{
+		{cap: "\x1b[%i%p1%d;%p2%dH", params: []any{0, 0}, want: "\x1b[1;1H"},
+		{cap: "\x1b[%i%p1%d;%p2%dH", params: []any{9, 79}, want: "\x1b[10;80H"},
+		{cap: "\x1b[%p1%dA$<5>", params: []any{3}, want: "\x1b[3A"},
+		{cap: "\x1b[3%p1%dm", params: []any{1}, want: "\x1b[31m"},
+		// xterm-256color's setaf
+		{cap: "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m", params: []any{3}, want: "\x1b[33m"},
+		{cap: "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m", params: []any{12}, want: "\x1b[94m"},
+		{cap: "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m", params: []any{200}, want: "\x1b[38;5;200m"},
+		// the linux console's initc
+		{cap: "\x1b]P%p1%x%p2%{255}%*%{1000}%/%02x%p3%{255}%*%{1000}%/%02x%p4%{255}%*%{1000}%/%02x", params: []any{10, 1000, 0, 500}, want: "\x1b]Paff007f"},
+		{cap: "%p1%c%p1%s%'A'%c%%", params: []any{66}, want: "B66A%"},
+		{cap: "%p1%l%d %p1%s", params: []any{"title"}, want: "5 title"},
+		{cap: "%p1%Pa%ga%ga%+%d", params: []any{4}, want: "8"},
+		{cap: "%p1%5d|%p1%:-5d|%p1%05d|%p1%:+d|%p1%x|%p1%X|%p1%o", params: []any{42}, want: "   42|42   |00042|+42|2a|2A|52"},
+		{cap: "%?%p1%t%?%p2%ta%eb%;%ec%;", params: []any{1, 0}, want: "b"},
+		{cap: "%?%p1%t%?%p2%ta%eb%;%ec%;", params: []any{0, 1}, want: "c"},
+		{cap: "%?%p1%{1}%=%tone%e%p1%{2}%=%ttwo%eother%;", params: []any{2}, want: "two"},
+		{cap: "%p1%p2%A%d%p1%p2%O%d%p1%!%d%p2%~%d", params: []any{1, 0}, want: "010-1"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.cap, func(t *testing.T) {
+			require.Equal(t, s.want, Tparm(s.cap, s.params...))
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package terminfo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Tparm returns the string of a capability with its parameters, see terminfo(5).
+// The parameters are ints or strings, and the padding of the capability is removed.
+func Tparm(s string, params ...any) string {
+	var p [9]any
+	for i := range p {
+		p[i] = 0
+	}
+	copy(p[:], params)
+	var (
+		out    strings.Builder
+		stack  []any
+		vars   [26]any
+		static [26]any
+	)
+	push := func(v any) { stack = append(stack, v) }
+	pop := func() any {
+		if len(stack) == 0 {
+			return 0
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	popInt := func() int {
+		switch v := pop().(type) {
+		case int:
+			return v
+		case string:
+			return len(v)
+		}
+		return 0
+	}
+	boolInt := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '$' && i+1 < len(s) && s[i+1] == '<' {
+			// padding, e.g. $<5>
+			if end := strings.IndexByte(s[i:], '>'); end >= 0 {
+				i += end
+				continue
+			}
+		}
+		if c != '%' || i+1 >= len(s) {
+			out.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case '%':
+			out.WriteByte('%')
+		case 'c':
+			out.WriteByte(byte(popInt()))
+		case 's':
+			v := pop()
+			if str, ok := v.(string); ok {
+				out.WriteString(str)
+			} else {
+				out.WriteString(strconv.Itoa(v.(int)))
+			}
+		case 'p':
+			if i+1 < len(s) && s[i+1] >= '1' && s[i+1] <= '9' {
+				i++
+				push(p[s[i]-'1'])
+			}
+		case 'P', 'g':
+			if i+1 >= len(s) {
+				break
+			}
+			i++
+			v := s[i]
+			var slot *any
+			switch {
+			case v >= 'a' && v <= 'z':
+				slot = &vars[v-'a']
+			case v >= 'A' && v <= 'Z':
+				slot = &static[v-'A']
+			default:
+				continue
+			}
+			if c == 'P' {
+				*slot = pop()
+			} else if *slot == nil {
+				push(0)
+			} else {
+				push(*slot)
+			}
+		case '\'':
+			if i+2 < len(s) && s[i+2] == '\'' {
+				push(int(s[i+1]))
+				i += 2
+			}
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				break
+			}
+			n, _ := strconv.Atoi(s[i+1 : i+end])
+			push(n)
+			i += end
+		case 'l':
+			v := pop()
+			str, _ := v.(string)
+			push(len(str))
+		case '+', '-', '*', '/', 'm', '&', '|', '^', '=', '>', '<', 'A', 'O':
+			b, a := popInt(), popInt()
+			switch c {
+			case '+':
+				push(a + b)
+			case '-':
+				push(a - b)
+			case '*':
+				push(a * b)
+			case '/':
+				if b != 0 {
+					push(a / b)
+				} else {
+					push(0)
+				}
+			case 'm':
+				if b != 0 {
+					push(a % b)
+				} else {
+					push(0)
+				}
+			case '&':
+				push(a & b)
+			case '|':
+				push(a | b)
+			case '^':
+				push(a ^ b)
+			case '=':
+				push(boolInt(a == b))
+			case '>':
+				push(boolInt(a > b))
+			case '<':
+				push(boolInt(a < b))
+			case 'A':
+				push(boolInt(a != 0 && b != 0))
+			case 'O':
+				push(boolInt(a != 0 || b != 0))
+			}
+		case '!':
+			push(boolInt(popInt() == 0))
+		case '~':
+			push(^popInt())
+		case 'i':
+			for j := 0; j < 2; j++ {
+				if n, ok := p[j].(int); ok {
+					p[j] = n + 1
+				}
+			}
+		case '?', ';':
+		case 't':
+			if popInt() == 0 {
+				i = skipConditional(s, i+1, true)
+			}
+		case 'e':
+			i = skipConditional(s, i+1, false)
+		default:
+			// a number printed with printf's flags, e.g. %d, %02x or %:-3d
+			end := i
+			for end < len(s) && strings.IndexByte(":-+# .0123456789", s[end]) >= 0 {
+				end++
+			}
+			if end < len(s) && strings.IndexByte("doxXs", s[end]) >= 0 {
+				out.WriteString(format(strings.TrimPrefix(s[i:end], ":"), s[end], pop()))
+				i = end
+			}
+		}
+	}
+	return out.String()
+}
+
+// skipConditional returns the index of the last character of the %e or the %; ending the part of a conditional
+// starting at i. When elsePart is false, only %; ends it.
+func skipConditional(s string, i int, elsePart bool) int {
+	depth := 0
+	for ; i < len(s)-1; i++ {
+		if s[i] != '%' {
+			continue
+		}
+		i++
+		switch s[i] {
+		case '?':
+			depth++
+		case ';':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case 'e':
+			if depth == 0 && elsePart {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// format formats v like printf with the flags, the width and the precision and the conversion.
+func format(flags string, conv byte, v any) string {
+	var s string
+	if conv == 's' {
+		if str, ok := v.(string); ok {
+			s = str
+		} else {
+			s = strconv.Itoa(v.(int))
+		}
+	} else {
+		n, _ := v.(int)
+		switch conv {
+		case 'o':
+			s = strconv.FormatInt(int64(n), 8)
+		case 'x':
+			s = strconv.FormatInt(int64(n), 16)
+		case 'X':
+			s = strings.ToUpper(strconv.FormatInt(int64(n), 16))
+		default:
+			s = strconv.Itoa(n)
+		}
+	}
+
+	left, zero, sign, space := false, false, false, false
+	i := 0
+	for ; i < len(flags) && strings.IndexByte("-+# 0", flags[i]) >= 0; i++ {
+		switch flags[i] {
+		case '-':
+			left = true
+		case '0':
+			zero = true
+		case '+':
+			sign = true
+		case ' ':
+			space = true
+		}
+	}
+	width, precision := flags[i:], ""
+	if dot := strings.IndexByte(width, '.'); dot >= 0 {
+		width, precision = width[:dot], width[dot+1:]
+	}
+	if p, err := strconv.Atoi(precision); err == nil && conv != 's' {
+		neg := strings.HasPrefix(s, "-")
+		digits := strings.TrimPrefix(s, "-")
+		if len(digits) < p {
+			digits = strings.Repeat("0", p-len(digits)) + digits
+		}
+		if s = digits; neg {
+			s = "-" + s
+		}
+	} else if err == nil && len(s) > p {
+		s = s[:p]
+	}
+	if conv == 'd' && !strings.HasPrefix(s, "-") {
+		if sign {
+			s = "+" + s
+		} else if space {
+			s = " " + s
+		}
+	}
+	if w, err := strconv.Atoi(width); err == nil && len(s) < w {
+		pad := strings.Repeat(" ", w-len(s))
+		switch {
+		case left:
+			s += pad
+		case zero && conv != 's':
+			s = strings.Repeat("0", w-len(s)) + s
+		default:
+			s = pad + s
+		}
+	}
+	return s
+}
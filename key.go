@@ -128,4 +128,18 @@ const (
 
 	// Key is not defined
 	NotDefined
+
+	// Alt keys, which come last to keep the values of the keys above.
+	AltY
+	AltT
+	AltF
+	AltB
+	AltD
+	AltC
+	AltU
+	AltL
+	AltBackspace
 )
+
+// lastKey is the last key of this package, the keys added by RegisterKey come after it.
+const lastKey = AltBackspace
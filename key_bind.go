@@ -4,9 +4,11 @@ package prompt
 type KeyBindFunc func(*Buffer)
 
 // KeyBind represents which key should do what operation.
+// Mods are the modifiers held with the key, which are only reported when OptionModifierKeys is used.
 type KeyBind struct {
-	Key Key
-	Fn  KeyBindFunc
+	Key  Key
+	Mods Modifiers
+	Fn   KeyBindFunc
 }
 
 // ASCIICodeBind represents which []byte should do what operation
@@ -23,6 +25,8 @@ const (
 	CommonKeyBind KeyBindMode = "common"
 	// EmacsKeyBind is a mode to use emacs-like keyboard shortcut
 	EmacsKeyBind KeyBindMode = "emacs"
+	// ViKeyBind is a mode to use vi-like keyboard shortcut with a normal and an insert mode
+	ViKeyBind KeyBindMode = "vi"
 )
 
 var commonKeyBindings = []KeyBind{
@@ -56,4 +60,14 @@ var commonKeyBindings = []KeyBind{
 		Key: Left,
 		Fn:  GoLeftChar,
 	},
+	// Forward one word
+	{
+		Key: ControlRight,
+		Fn:  GoRightWord,
+	},
+	// Backward one word
+	{
+		Key: ControlLeft,
+		Fn:  GoLeftWord,
+	},
 }
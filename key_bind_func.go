@@ -1,5 +1,11 @@
 package prompt
 
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
 // GoLineEnd Go to the End of the line
 func GoLineEnd(buf *Buffer) {
 	x := []rune(buf.Document().TextAfterCursor())
@@ -46,3 +52,48 @@ func GoRightWord(buf *Buffer) {
 func GoLeftWord(buf *Buffer) {
 	buf.CursorLeft(len([]rune(buf.Document().TextBeforeCursor())) - buf.Document().FindStartOfPreviousWordWithSpace())
 }
+
+// KillWord Cut the word after the cursor
+func KillWord(buf *Buffer) {
+	buf.Kill(buf.Document().FindEndOfCurrentWordWithSpace())
+}
+
+// BackwardKillWord Cut the word before the cursor
+func BackwardKillWord(buf *Buffer) {
+	buf.KillBeforeCursor(len([]rune(buf.Document().TextBeforeCursor())) - buf.Document().FindStartOfPreviousWordWithSpace())
+}
+
+// UpcaseWord Uppercase the word after the cursor and go to its end
+func UpcaseWord(buf *Buffer) {
+	replaceWordAfterCursor(buf, strings.ToUpper)
+}
+
+// DowncaseWord Lowercase the word after the cursor and go to its end
+func DowncaseWord(buf *Buffer) {
+	replaceWordAfterCursor(buf, strings.ToLower)
+}
+
+// CapitalizeWord Capitalize the word after the cursor and go to its end
+func CapitalizeWord(buf *Buffer) {
+	replaceWordAfterCursor(buf, func(word string) string {
+		i := strings.IndexFunc(word, unicode.IsLetter)
+		if i == -1 {
+			return word
+		}
+		r, size := utf8.DecodeRuneInString(word[i:])
+		return word[:i] + string(unicode.ToUpper(r)) + strings.ToLower(word[i+size:])
+	})
+}
+
+// TransposeWords Swap the word before the cursor with the word after it
+func TransposeWords(buf *Buffer) {
+	buf.TransposeWords()
+}
+
+// replaceWordAfterCursor replaces the word after the cursor, with the spaces before it, by fn of it and moves the cursor after it.
+func replaceWordAfterCursor(buf *Buffer, fn func(word string) string) {
+	word := buf.Delete(buf.Document().FindEndOfCurrentWordWithSpace())
+	if word != "" {
+		buf.InsertText(fn(word), false, true)
+	}
+}
@@ -0,0 +1,204 @@
+package prompt
+
+import (
+	"bytes"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Modifiers are the modifier keys held with a key. The values are those of the kitty keyboard protocol.
+type Modifiers int
+
+const (
+	ModShift Modifiers = 1 << iota
+	ModAlt
+	ModControl
+	ModSuper
+
+	// modMask leaves out the other modifiers reported by the terminals, e.g. Caps Lock and Num Lock.
+	modMask = ModShift | ModAlt | ModControl | ModSuper
+)
+
+// KeyEvent is a key with the modifiers held with it. Key is the key the terminal sends without the keyboard protocol,
+// e.g. ControlA for Ctrl+A, and Mods only has the modifiers which Key doesn't express, e.g. ModShift for Ctrl+Shift+A.
+// Rune is the character of the key, if it has one.
+type KeyEvent struct {
+	Key  Key
+	Rune rune
+	Mods Modifiers
+}
+
+const (
+	// the sequences enabling and disabling the kitty keyboard protocol, and xterm's modifyOtherKeys which is used when
+	// the terminal doesn't know the kitty protocol.
+	kittyKeyboardPush  = "\x1b[>1u"
+	kittyKeyboardPop   = "\x1b[<u"
+	modifyOtherKeysSet = "\x1b[>4;2m"
+	modifyOtherKeysRst = "\x1b[>4m"
+
+	// the range of the codepoints of the kitty protocol for the keys without a character, e.g. the keypad.
+	kittyPrivateUseFirst = 57344
+	kittyPrivateUseLast  = 63743
+)
+
+// GetKeyEvent returns the key event of the bytes of a key, decoding the modifiers of the CSI u sequences
+// of the kitty keyboard protocol, of xterm's modifyOtherKeys and of the modified function keys, e.g. ESC [ 1 ; 3 A for Alt+Up.
+func GetKeyEvent(b []byte) KeyEvent {
+	ev, _ := decodeKeyEvent(b)
+	return ev
+}
+
+// decodeKeyEvent returns the key event of b, and the bytes the terminal sends for the key without the keyboard protocol.
+func decodeKeyEvent(b []byte) (ev KeyEvent, legacy []byte) {
+	ev.Key = GetKey(b)
+	if ev.Key != Ignore {
+		if ev.Key == NotDefined {
+			if r, size := utf8.DecodeRune(b); size == len(b) && r != utf8.RuneError {
+				ev.Rune = r
+			}
+		}
+		return ev, b
+	}
+	params, final, ok := parseCSI(b)
+	if !ok {
+		return ev, b
+	}
+	switch {
+	case final == 'u' && len(params) >= 1:
+		// CSI code[:shifted] ; mods[:event] u
+		if len(params) >= 2 && len(params[1]) >= 2 && params[1][1] == 3 { // key release
+			return ev, b
+		}
+		code, mods := params[0][0], modifiersParam(params)
+		if len(params[0]) >= 2 && params[0][1] > 0 && mods&ModShift != 0 && mods&ModControl == 0 {
+			code, mods = params[0][1], mods&^ModShift
+		}
+		return codepointKeyEvent(rune(code), mods, b)
+	case final == '~' && len(params) >= 3 && params[0][0] == 27:
+		// xterm's modifyOtherKeys: CSI 27 ; mods ; code ~
+		return codepointKeyEvent(rune(params[2][0]), modifiersParam(params), b)
+	case len(params) == 2 && final == '~':
+		// a modified function key: CSI n ; mods ~
+		return functionKeyEvent([]byte("\x1b["+strconv.Itoa(params[0][0])+"~"), modifiersParam(params), b)
+	case len(params) == 2 && params[0][0] == 1 && bytes.IndexByte([]byte("ABCDHF"), final) >= 0:
+		// a modified cursor key: CSI 1 ; mods X
+		return functionKeyEvent([]byte{0x1b, '[', final}, modifiersParam(params), b)
+	case len(params) == 2 && params[0][0] == 1 && bytes.IndexByte([]byte("PQRS"), final) >= 0:
+		// a modified F1 to F4: CSI 1 ; mods P
+		return functionKeyEvent([]byte{0x1b, 'O', final}, modifiersParam(params), b)
+	}
+	return ev, b
+}
+
+// codepointKeyEvent returns the key event of a key with a character, whose modifiers are applied to the character
+// like a terminal does without the keyboard protocol when it's possible.
+func codepointKeyEvent(r rune, mods Modifiers, b []byte) (KeyEvent, []byte) {
+	if r >= kittyPrivateUseFirst && r <= kittyPrivateUseLast || !utf8.ValidRune(r) {
+		return KeyEvent{Key: Ignore}, b
+	}
+	ev := KeyEvent{Rune: r, Mods: mods}
+	var legacy []byte
+	switch {
+	case r == '\t' && mods&ModShift != 0:
+		legacy, ev.Mods = []byte{0x1b, '[', 'Z'}, ev.Mods&^ModShift
+	case r == '\r' || r == '\t' || r == 0x1b || r == 0x7f:
+		legacy = []byte{byte(r)}
+	case mods&ModControl != 0 && controlCode(r) >= 0:
+		legacy, ev.Mods = []byte{byte(controlCode(r))}, ev.Mods&^ModControl
+	case mods&ModControl == 0 && unicode.IsPrint(r) && r != ' ' && mods&ModShift != 0:
+		legacy, ev.Mods = []byte(string(unicode.ToUpper(r))), ev.Mods&^ModShift
+	default:
+		legacy = []byte(string(r))
+	}
+	if ev.Mods&ModAlt != 0 {
+		legacy, ev.Mods = append([]byte{0x1b}, legacy...), ev.Mods&^ModAlt
+	}
+	ev.Key = GetKey(legacy)
+	return ev, legacy
+}
+
+// functionKeyEvent returns the key event of a modified key without a character, whose bytes without the modifiers are base.
+// The sequences of the modified keys which are known, e.g. ControlLeft, are returned by GetKey before.
+func functionKeyEvent(base []byte, mods Modifiers, b []byte) (KeyEvent, []byte) {
+	key := GetKey(base)
+	if key == Ignore || key == NotDefined {
+		return KeyEvent{Key: Ignore}, b
+	}
+	return KeyEvent{Key: key, Mods: mods}, base
+}
+
+// controlCode returns the control character sent for Ctrl and r, -1 when there is none.
+func controlCode(r rune) int {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return int(r-'a') + 1
+	case r >= '@' && r <= '_':
+		return int(r - '@')
+	case r == ' ':
+		return 0
+	case r == '?':
+		return 0x7f
+	}
+	return -1
+}
+
+// modifiersParam returns the modifiers of the second parameter of a sequence, which is 1 plus the modifier bits.
+func modifiersParam(params [][]int) Modifiers {
+	if len(params) < 2 || params[1][0] < 1 {
+		return 0
+	}
+	return Modifiers(params[1][0]-1) & modMask
+}
+
+// parseCSI returns the numeric parameters of a CSI sequence, with their colon separated sub-parameters, and its final byte.
+// ok is false when b isn't a CSI sequence with numeric parameters.
+func parseCSI(b []byte) (params [][]int, final byte, ok bool) {
+	if len(b) < 3 || b[0] != 0x1b || b[1] != '[' || !isEscapeSequence(b) {
+		return nil, 0, false
+	}
+	final = b[len(b)-1]
+	if len(b) == 3 {
+		return nil, final, true
+	}
+	for _, p := range bytes.Split(b[2:len(b)-1], []byte{';'}) {
+		var param []int
+		for _, s := range bytes.Split(p, []byte{':'}) {
+			n := 0
+			if len(s) > 0 {
+				var err error
+				if n, err = strconv.Atoi(string(s)); err != nil {
+					return nil, 0, false
+				}
+			}
+			param = append(param, n)
+		}
+		params = append(params, param)
+	}
+	return params, final, true
+}
+
+// setInputModes enables or disables the input modes of the terminal used by the prompt: the bracketed paste mode,
+// the keyboard protocols reporting the modifiers when OptionModifierKeys is used and the mouse reports when OptionMouse is used.
+func (p *Prompt) setInputModes(enabled bool) {
+	if enabled {
+		p.renderer.out.WriteRawStr("\x1b[?2004h")
+		if p.modifierKeys {
+			p.renderer.out.WriteRawStr(kittyKeyboardPush + modifyOtherKeysSet)
+		}
+		if p.mouse {
+			p.renderer.out.WriteRawStr(mouseOn)
+			p.mouseState.active = true
+		}
+	} else {
+		if p.mouse {
+			p.renderer.out.WriteRawStr(mouseOff)
+		}
+		// the answers to the pending requests can't be told from the input any more
+		p.mouseState = mouseState{}
+		if p.modifierKeys {
+			p.renderer.out.WriteRawStr(modifyOtherKeysRst + kittyKeyboardPop)
+		}
+		p.renderer.out.WriteRawStr("\x1b[?2004l")
+	}
+}
@@ -0,0 +1,134 @@
+package prompt
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetKeyEvent(t *testing.T) {
+	scenarios := []struct {
+		name   string
+		input  string
+		want   KeyEvent
+		legacy string
+	}{
+		{name: "plain key", input: "\x1b[A", want: KeyEvent{Key: Up}, legacy: "\x1b[A"},
+		{name: "text", input: "é", want: KeyEvent{Key: NotDefined, Rune: 'é'}, legacy: "é"},
+		{name: "known modified key", input: "\x1b[1;5D", want: KeyEvent{Key: ControlLeft}, legacy: "\x1b[1;5D"},
+		{name: "shift+enter", input: "\x1b[13;2u", want: KeyEvent{Key: ControlM, Rune: '\r', Mods: ModShift}, legacy: "\r"},
+		{name: "alt+enter", input: "\x1b[13;3u", want: KeyEvent{Key: AltEnter, Rune: '\r'}, legacy: "\x1b\r"},
+		{name: "escape", input: "\x1b[27u", want: KeyEvent{Key: Escape, Rune: 0x1b}, legacy: "\x1b"},
+		{name: "ctrl+a", input: "\x1b[97;5u", want: KeyEvent{Key: ControlA, Rune: 'a'}, legacy: "\x01"},
+		{name: "ctrl+shift+a", input: "\x1b[97;6u", want: KeyEvent{Key: ControlA, Rune: 'a', Mods: ModShift}, legacy: "\x01"},
+		{name: "shift+a", input: "\x1b[97;2u", want: KeyEvent{Key: NotDefined, Rune: 'a'}, legacy: "A"},
+		{name: "shifted key", input: "\x1b[49:33;2u", want: KeyEvent{Key: NotDefined, Rune: '!'}, legacy: "!"},
+		{name: "shift+tab", input: "\x1b[9;2u", want: KeyEvent{Key: BackTab, Rune: '\t'}, legacy: "\x1b[Z"},
+		{name: "alt+f", input: "\x1b[102;3u", want: KeyEvent{Key: AltF, Rune: 'f'}, legacy: "\x1bf"},
+		{name: "ctrl+1", input: "\x1b[49;5u", want: KeyEvent{Key: NotDefined, Rune: '1', Mods: ModControl}, legacy: "1"},
+		{name: "caps lock is ignored", input: "\x1b[13;66u", want: KeyEvent{Key: ControlM, Rune: '\r', Mods: ModShift}, legacy: "\r"},
+		{name: "release", input: "\x1b[13;2:3u", want: KeyEvent{Key: Ignore}, legacy: "\x1b[13;2:3u"},
+		{name: "private use key", input: "\x1b[57399u", want: KeyEvent{Key: Ignore}, legacy: "\x1b[57399u"},
+		{name: "modifyOtherKeys", input: "\x1b[27;2;13~", want: KeyEvent{Key: ControlM, Rune: '\r', Mods: ModShift}, legacy: "\r"},
+		{name: "modifyOtherKeys ctrl+a", input: "\x1b[27;5;97~", want: KeyEvent{Key: ControlA, Rune: 'a'}, legacy: "\x01"},
+		{name: "alt+up", input: "\x1b[1;3A", want: KeyEvent{Key: Up, Mods: ModAlt}, legacy: "\x1b[A"},
+		{name: "ctrl+shift+up", input: "\x1b[1;6A", want: KeyEvent{Key: Up, Mods: ModControl | ModShift}, legacy: "\x1b[A"},
+		{name: "alt+f1", input: "\x1b[1;3P", want: KeyEvent{Key: F1, Mods: ModAlt}, legacy: "\x1bOP"},
+		{name: "alt+delete", input: "\x1b[3;3~", want: KeyEvent{Key: Delete, Mods: ModAlt}, legacy: "\x1b[3~"},
+		{name: "unknown sequence", input: "\x1b[1;3Y", want: KeyEvent{Key: Ignore}, legacy: "\x1b[1;3Y"},
+		{name: "private sequence", input: "\x1b[?1u", want: KeyEvent{Key: Ignore}, legacy: "\x1b[?1u"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			ev, legacy := decodeKeyEvent([]byte(s.input))
+			require.Equal(t, s.want, ev)
+			require.Equal(t, s.legacy, string(legacy))
+			require.Equal(t, s.want, GetKeyEvent([]byte(s.input)))
+		})
+	}
+}
+
+func TestFeedModifierKeys(t *testing.T) {
+	newPrompt := func(modifierKeys bool, bindings ...KeyBind) *Prompt {
+		p := newTestPrompt(EmacsKeyBind)
+		p.renderer = &Render{out: NoopWriter{}, col: 80}
+		p.keyBindings = bindings
+		p.modifierKeys = modifierKeys
+		p.lexer = NewLexer()
+		p.statementTerminatorCb = func(Key, *Buffer) bool { return true }
+		p.renderer.livePrefixCallback = func() (string, bool) { return "", false }
+		p.buf.InsertText("abc", false, true)
+		return p
+	}
+
+	t.Run("shift+enter breaks the line", func(t *testing.T) {
+		p := newPrompt(true)
+		exec, err := p.feed([]byte("\x1b[13;2u"))
+		require.NoError(t, err)
+		require.Nil(t, exec)
+		require.Equal(t, "abc\n", p.buf.Text())
+
+		exec, err = p.feed([]byte("\r"))
+		require.NoError(t, err)
+		require.NotNil(t, exec)
+		require.Equal(t, "abc\n", exec.input)
+	})
+
+	t.Run("ctrl+a without a binding", func(t *testing.T) {
+		p := newPrompt(true)
+		_, err := p.feed([]byte("\x1b[97;5u"))
+		require.NoError(t, err)
+		require.Equal(t, 0, p.buf.Document().cursorPosition)
+	})
+
+	t.Run("binding with modifiers", func(t *testing.T) {
+		var called []string
+		p := newPrompt(true,
+			KeyBind{Key: ControlA, Mods: ModShift, Fn: func(*Buffer) { called = append(called, "ctrl+shift+a") }},
+			KeyBind{Key: ControlA, Fn: func(*Buffer) { called = append(called, "ctrl+a") }},
+		)
+		_, err := p.feed([]byte("\x1b[97;6u"))
+		require.NoError(t, err)
+		// the binding replaces Ctrl+A
+		require.Equal(t, []string{"ctrl+shift+a"}, called)
+		require.Equal(t, 3, p.buf.Document().cursorPosition)
+
+		_, err = p.feed([]byte("\x01"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"ctrl+shift+a", "ctrl+a"}, called)
+		require.Equal(t, 0, p.buf.Document().cursorPosition)
+	})
+
+	t.Run("typed text", func(t *testing.T) {
+		p := newPrompt(true)
+		_, err := p.feed([]byte("\x1b[100;2u"))
+		require.NoError(t, err)
+		require.Equal(t, "abcD", p.buf.Text())
+	})
+
+	t.Run("without the option", func(t *testing.T) {
+		p := newPrompt(false, KeyBind{Key: Up, Mods: ModAlt, Fn: func(b *Buffer) { b.InsertText("!", false, true) }})
+		_, err := p.feed([]byte("\x1b[1;3A"))
+		require.NoError(t, err)
+		require.Equal(t, "abc", p.buf.Text())
+	})
+}
+
+func TestModifierKeysMode(t *testing.T) {
+	p := newFileInputPrompt(t)
+	require.NoError(t, OptionModifierKeys()(p))
+	out := &recordingWriter{}
+	p.Renderer().out = out
+
+	go p.Accept()
+	_, err := p.InputContext(context.Background())
+	require.NoError(t, err)
+
+	enabled := strings.Index(out.String(), kittyKeyboardPush+modifyOtherKeysSet)
+	disabled := strings.LastIndex(out.String(), modifyOtherKeysRst+kittyKeyboardPop)
+	require.GreaterOrEqual(t, enabled, 0)
+	require.Greater(t, disabled, enabled)
+}
@@ -0,0 +1,96 @@
+package prompt
+
+import "sync"
+
+// DefaultKillRingSize is the number of entries kept by the kill ring of a prompt.
+const DefaultKillRingSize = 60
+
+// KillRing stores the texts cut by the kill commands (Ctrl+K, Ctrl+U and Ctrl+W), so that they can be yanked later.
+// It is safe to use from any goroutine.
+type KillRing struct {
+	mu      sync.Mutex
+	entries []string // oldest first
+	size    int
+	yanked  int // index of the last yanked entry counted from the newest one
+}
+
+// NewKillRing returns an empty kill ring keeping at most size entries.
+func NewKillRing(size int) *KillRing {
+	return &KillRing{size: max(size, 1)}
+}
+
+// Push adds the text as the newest entry, dropping the oldest entry when the ring is full.
+func (k *KillRing) Push(text string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.push(text)
+}
+
+func (k *KillRing) push(text string) {
+	k.entries = append(k.entries, text)
+	if len(k.entries) > k.size {
+		k.entries = k.entries[len(k.entries)-k.size:]
+	}
+	k.yanked = 0
+}
+
+// Entries returns the entries, the newest first.
+func (k *KillRing) Entries() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entries := make([]string, len(k.entries))
+	for i, e := range k.entries {
+		entries[len(k.entries)-1-i] = e
+	}
+	return entries
+}
+
+// Len returns the number of entries.
+func (k *KillRing) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.entries)
+}
+
+// kill adds the killed text, it's appended to the newest entry when the previous command was a kill as well.
+func (k *KillRing) kill(text string, appendToLatest bool, before bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if !appendToLatest || len(k.entries) == 0 {
+		k.push(text)
+		return
+	}
+	latest := &k.entries[len(k.entries)-1]
+	if before {
+		*latest = text + *latest
+	} else {
+		*latest += text
+	}
+	k.yanked = 0
+}
+
+// yank returns the newest entry.
+func (k *KillRing) yank() (string, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if len(k.entries) == 0 {
+		return "", false
+	}
+	k.yanked = 0
+	return k.entries[len(k.entries)-1], true
+}
+
+// rotate returns the entry before the last yanked one, wrapping around to the newest entry.
+func (k *KillRing) rotate() (string, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if len(k.entries) == 0 {
+		return "", false
+	}
+	k.yanked = (k.yanked + 1) % len(k.entries)
+	return k.entries[len(k.entries)-1-k.yanked], true
+}
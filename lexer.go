@@ -3,12 +3,22 @@ package prompt
 // LexerFunc is a callback from render.
 type LexerFunc = func(line string) []LexerElement
 
-// LexerElement is a element of lexer.
+// LexerElement is a element of lexer. Its text is rendered with Style, and with Color when Style has no color.
+// The default colors of the style are those of the input.
 type LexerElement struct {
 	Color Color
+	Style Style
 	Text  string
 }
 
+func (e LexerElement) style() Style {
+	s := e.Style
+	if s.Fg == DefaultColor {
+		s.Fg = e.Color
+	}
+	return s
+}
+
 // Lexer is a struct with lexer param and function.
 type Lexer struct {
 	IsEnabled bool
@@ -0,0 +1,225 @@
+package prompt
+
+import (
+	"bytes"
+	"strconv"
+)
+
+const (
+	// mouseOn enables the reports of the mouse buttons and the wheel, in the SGR 1006 format, mouseOff disables them.
+	mouseOn  = "\x1b[?1000h\x1b[?1006h"
+	mouseOff = "\x1b[?1006l\x1b[?1000l"
+	// requestCursorPosition asks the terminal for the position of the cursor, which is answered with ESC [ row ; column R.
+	requestCursorPosition = "\x1b[6n"
+	// maxCursorPositionRequests is the number of requests of the position of the cursor waiting for an answer,
+	// no more are sent when the terminal doesn't answer.
+	maxCursorPositionRequests = 4
+)
+
+// MouseButton is the button of a mouse event.
+type MouseButton int
+
+const (
+	MouseLeft MouseButton = iota
+	MouseMiddle
+	MouseRight
+	MouseWheelUp
+	MouseWheelDown
+)
+
+// MouseEvent is a button pressed at a cell of the screen, whose top left cell is 0, 0.
+type MouseEvent struct {
+	Button MouseButton
+	X, Y   int
+	Mods   Modifiers
+}
+
+// isMouseSequence returns whether b is a mouse report in the SGR format, ESC [ < button ; x ; y M or m.
+func isMouseSequence(b []byte) bool {
+	return bytes.HasPrefix(b, []byte("\x1b[<")) && isEscapeSequence(b) && (b[len(b)-1] == 'M' || b[len(b)-1] == 'm')
+}
+
+// parseMouseEvent returns the mouse event of an SGR mouse report. ok is false for the releases of the buttons,
+// the motions and the buttons which are not known.
+func parseMouseEvent(b []byte) (ev MouseEvent, ok bool) {
+	if !isMouseSequence(b) || b[len(b)-1] == 'm' {
+		return ev, false
+	}
+	params := bytes.Split(b[3:len(b)-1], []byte{';'})
+	if len(params) != 3 {
+		return ev, false
+	}
+	var n [3]int
+	for i, p := range params {
+		var err error
+		if n[i], err = strconv.Atoi(string(p)); err != nil {
+			return ev, false
+		}
+	}
+	button := n[0]
+	if button&4 != 0 {
+		ev.Mods |= ModShift
+	}
+	if button&8 != 0 {
+		ev.Mods |= ModAlt
+	}
+	if button&16 != 0 {
+		ev.Mods |= ModControl
+	}
+	switch button &^ (4 | 8 | 16) {
+	case 0:
+		ev.Button = MouseLeft
+	case 1:
+		ev.Button = MouseMiddle
+	case 2:
+		ev.Button = MouseRight
+	case 64:
+		ev.Button = MouseWheelUp
+	case 65:
+		ev.Button = MouseWheelDown
+	default:
+		return ev, false
+	}
+	ev.X, ev.Y = n[1]-1, n[2]-1
+	return ev, true
+}
+
+// parseCursorPosition returns the row and the column, from 0, of a cursor position report ESC [ row ; column R.
+func parseCursorPosition(b []byte) (row, col int, ok bool) {
+	params, final, ok := parseCSI(b)
+	if !ok || final != 'R' || len(params) != 2 || len(params[0]) != 1 || len(params[1]) != 1 {
+		return 0, 0, false
+	}
+	return params[0][0] - 1, params[1][0] - 1, true
+}
+
+// mouseState keeps what's needed to find what's under the mouse when OptionMouse is used.
+type mouseState struct {
+	// active is true while the terminal is in raw mode with the mouse enabled, so that the answers are read as input.
+	active bool
+	// the relative positions of the cursor when the position of the cursor on the screen was requested, oldest first.
+	requests []int
+	// top is the row of the screen where the prompt starts, once located is true.
+	top     int
+	located bool
+	// screen is what was rendered when the position of the cursor was last requested.
+	screen screen
+}
+
+// screen is what a render shows, the prompt can only move on the screen when it changes.
+type screen struct {
+	prefix, text, autoSuggestion string
+	cursor                       int
+	selected                     int
+	menu                         completionMenu
+	diagnostics                  int
+	row, col                     uint16
+}
+
+// screen returns what the last render showed.
+func (p *Prompt) screen() screen {
+	return screen{
+		prefix:         p.renderer.getCurrentPrefix(),
+		text:           p.buf.Text(),
+		autoSuggestion: p.renderer.autoSuggestion,
+		cursor:         p.renderer.previousCursor,
+		selected:       p.completion.GetSelectedIdx(),
+		menu:           p.renderer.completionMenu,
+		diagnostics:    len(p.diagnostics),
+		row:            p.renderer.row,
+		col:            p.renderer.col,
+	}
+}
+
+// requestCursorPosition asks the terminal where the cursor is after rendering, to know where the prompt is on the screen.
+// Nothing is asked while the terminal isn't in raw mode, since it would echo the answer,
+// nor when the screen is the same as when it was last asked.
+func (p *Prompt) requestCursorPosition() {
+	if !p.mouseState.active || p.renderer.col == 0 || len(p.mouseState.requests) >= maxCursorPositionRequests {
+		return
+	}
+	s := p.screen()
+	if s == p.mouseState.screen {
+		return
+	}
+	p.renderer.out.WriteRawStr(requestCursorPosition)
+	p.mouseState.requests = append(p.mouseState.requests, p.renderer.previousCursor)
+	p.mouseState.screen = s
+}
+
+// handleCursorPosition handles the answer to requestCursorPosition. It returns false when b is not expected,
+// e.g. when the terminal doesn't answer, and it's then handled as a key.
+func (p *Prompt) handleCursorPosition(b []byte) bool {
+	if len(p.mouseState.requests) == 0 {
+		return false
+	}
+	row, _, ok := parseCursorPosition(b)
+	if !ok {
+		return false
+	}
+	_, y := p.renderer.toPos(p.mouseState.requests[0])
+	p.mouseState.requests = p.mouseState.requests[1:]
+	p.mouseState.top, p.mouseState.located = row-y, true
+	return true
+}
+
+// handleMouse selects the clicked suggestion, scrolls the completion menu with the wheel
+// and moves the cursor to the clicked character of the input.
+func (p *Prompt) handleMouse(b []byte) {
+	ev, ok := parseMouseEvent(b)
+	if !ok || !p.mouseState.located || p.search.active {
+		return
+	}
+	x, y := ev.X, ev.Y-p.mouseState.top
+	switch ev.Button {
+	case MouseWheelUp:
+		p.completion.scroll(-1)
+	case MouseWheelDown:
+		p.completion.scroll(1)
+	case MouseLeft:
+		if i, ok := p.renderer.suggestionAt(x, y); ok {
+			p.completion.selectSuggestion(i)
+		} else if i, ok := p.renderer.cursorAt(p.buf.Text(), x, y); ok {
+			p.completion.Reset()
+			p.buf.setCursorPosition(i)
+			p.buf.preferredColumn = -1
+		}
+	}
+}
+
+// completionMenu is where the completion menu was rendered, relatively to the beginning of the prompt.
+type completionMenu struct {
+	x, y          int // the top left cell of the first suggestion
+	width, height int
+	scroll        int // the index of the first suggestion
+}
+
+// suggestionAt returns the index of the suggestion rendered at x, y relatively to the beginning of the prompt.
+func (r *Render) suggestionAt(x, y int) (int, bool) {
+	m := r.completionMenu
+	if x < m.x || x >= m.x+m.width || y < m.y || y >= m.y+m.height {
+		return 0, false
+	}
+	return m.scroll + y - m.y, true
+}
+
+// cursorAt returns the index of the character of text rendered at x, y relatively to the beginning of the prompt,
+// or the end of the line when x is after it.
+func (r *Render) cursorAt(text string, x, y int) (int, bool) {
+	if r.col == 0 {
+		return 0, false
+	}
+	prefix := r.getCurrentPrefix()
+	runes := []rune(text)
+	index := -1
+	for i := 0; i <= len(runes); i++ {
+		px, py := r.toPos(r.getCursorEndPos(prefix+string(runes[:i]), 0))
+		if py > y {
+			break
+		}
+		if py == y && (px <= x || index == -1) {
+			index = i
+		}
+	}
+	return index, index >= 0
+}
@@ -0,0 +1,206 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMouseEvent(t *testing.T) {
+	scenarios := []struct {
+		input string
+		want  MouseEvent
+		ok    bool
+	}{
+		{input: "\x1b[<0;3;5M", want: MouseEvent{Button: MouseLeft, X: 2, Y: 4}, ok: true},
+		{input: "\x1b[<2;1;1M", want: MouseEvent{Button: MouseRight}, ok: true},
+		{input: "\x1b[<16;10;2M", want: MouseEvent{Button: MouseLeft, X: 9, Y: 1, Mods: ModControl}, ok: true},
+		{input: "\x1b[<64;1;1M", want: MouseEvent{Button: MouseWheelUp}, ok: true},
+		{input: "\x1b[<65;1;1M", want: MouseEvent{Button: MouseWheelDown}, ok: true},
+		{input: "\x1b[<0;3;5m"},  // release
+		{input: "\x1b[<32;3;5M"}, // motion
+		{input: "\x1b[<0;3M"},
+		{input: "\x1b[3;5M"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(fmt.Sprintf("%q", s.input), func(t *testing.T) {
+			ev, ok := parseMouseEvent([]byte(s.input))
+			require.Equal(t, s.ok, ok)
+			if s.ok {
+				require.Equal(t, s.want, ev)
+			}
+		})
+	}
+	require.Equal(t, Vt100MouseEvent, GetKey([]byte("\x1b[<0;3;5m")))
+}
+
+func TestFeedMouse(t *testing.T) {
+	newPrompt := func() *Prompt {
+		p := newTestPrompt(EmacsKeyBind)
+		p.renderer = &Render{
+			prefix:             "> ",
+			out:                &recordingWriter{},
+			livePrefixCallback: func() (string, bool) { return "", false },
+			col:                80,
+		}
+		p.lexer = NewLexer()
+		p.completion = NewCompletionManager(func(Document) []Suggest {
+			return []Suggest{{Text: "a"}, {Text: "b"}, {Text: "c"}, {Text: "d"}, {Text: "e"}}
+		}, 3)
+		p.mouse = true
+		p.setInputModes(true)
+		p.buf.InsertText("select foo\nfrom bar", false, true)
+		p.completion.Update(*p.buf.Document())
+		p.Render()
+		require.Contains(t, p.renderer.out.(*recordingWriter).String(), requestCursorPosition)
+		// the cursor is at the end of the second line, on the sixth row of the screen
+		require.True(t, p.handleCursorPosition([]byte("\x1b[6;9R")))
+		require.Equal(t, 4, p.mouseState.top)
+		return p
+	}
+	feed := func(t *testing.T, p *Prompt, s string) {
+		_, err := p.feed([]byte(s))
+		require.NoError(t, err)
+	}
+
+	t.Run("click on the input", func(t *testing.T) {
+		p := newPrompt()
+		feed(t, p, "\x1b[<0;5;5M")
+		require.Equal(t, 2, p.buf.Document().cursorPosition)
+		feed(t, p, "\x1b[<0;2;5M") // on the prefix
+		require.Equal(t, 0, p.buf.Document().cursorPosition)
+		feed(t, p, "\x1b[<0;3;6M")
+		require.Equal(t, 13, p.buf.Document().cursorPosition)
+		feed(t, p, "\x1b[<0;40;6M") // after the end of the line
+		require.Equal(t, 19, p.buf.Document().cursorPosition)
+		feed(t, p, "\x1b[<0;3;2M") // above the prompt
+		require.Equal(t, 19, p.buf.Document().cursorPosition)
+		require.Equal(t, "select foo\nfrom bar", p.buf.Text())
+	})
+
+	t.Run("click on a suggestion", func(t *testing.T) {
+		p := newPrompt()
+		m := p.renderer.completionMenu
+		require.Equal(t, 3, m.height)
+		feed(t, p, fmt.Sprintf("\x1b[<0;%d;%dM", m.x+1, 4+m.y+2))
+		require.Equal(t, 1, p.completion.GetSelectedIdx())
+		require.Equal(t, "select foo\nfrom bar", p.buf.Text())
+	})
+
+	t.Run("wheel", func(t *testing.T) {
+		p := newPrompt()
+		for i := 0; i < 3; i++ {
+			feed(t, p, "\x1b[<65;1;1M")
+		}
+		require.Equal(t, 2, p.completion.GetVerticalScroll())
+		require.Equal(t, -1, p.completion.GetSelectedIdx())
+		feed(t, p, "\x1b[<64;1;1M")
+		require.Equal(t, 1, p.completion.GetVerticalScroll())
+
+		// the selected suggestion stays visible
+		p.completion.Next()
+		require.Equal(t, 0, p.completion.GetVerticalScroll())
+		feed(t, p, "\x1b[<65;1;1M")
+		require.Equal(t, 1, p.completion.GetVerticalScroll())
+		require.Equal(t, 1, p.completion.GetSelectedIdx())
+	})
+
+	t.Run("unexpected cursor position report is a key", func(t *testing.T) {
+		p := newPrompt()
+		p.mouseState.requests = nil
+		var called bool
+		p.keyBindings = []KeyBind{{Key: F16, Fn: func(*Buffer) { called = true }}}
+		require.False(t, p.handleCursorPosition([]byte("\x1b[1;2R")))
+		feed(t, p, "\x1b[1;2R")
+		require.True(t, called)
+	})
+}
+
+func TestRequestCursorPosition(t *testing.T) {
+	p := newTestPrompt(EmacsKeyBind)
+	out := &recordingWriter{}
+	p.renderer = &Render{out: out, col: 80, livePrefixCallback: func() (string, bool) { return "", false }}
+	p.lexer = NewLexer()
+	p.mouse = true
+
+	// not in raw mode
+	p.Render()
+	require.NotContains(t, out.String(), requestCursorPosition)
+
+	// the screen doesn't change
+	p.setInputModes(true)
+	p.Render()
+	p.Render()
+	require.Equal(t, 1, strings.Count(out.String(), requestCursorPosition))
+	require.True(t, p.handleCursorPosition([]byte("\x1b[3;1R")))
+	p.Render()
+	require.Equal(t, 1, strings.Count(out.String(), requestCursorPosition))
+
+	// the terminal doesn't answer
+	for i := 0; i < 10; i++ {
+		p.buf.InsertText("a", false, true)
+		p.Render()
+	}
+	require.Equal(t, 1+maxCursorPositionRequests, strings.Count(out.String(), requestCursorPosition))
+	require.Len(t, p.mouseState.requests, maxCursorPositionRequests)
+
+	p.setInputModes(false)
+	require.Empty(t, p.mouseState.requests)
+	p.Render()
+	require.Equal(t, 1+maxCursorPositionRequests, strings.Count(out.String(), requestCursorPosition))
+}
+
+func TestCursorPositionAnswerIsNotRendered(t *testing.T) {
+	p := newFileInputPrompt(t)
+	require.NoError(t, OptionMouse()(p))
+	require.NoError(t, OptionPrefix("> ")(p))
+	out := &recordingWriter{}
+	p.Renderer().out = out
+	require.NoError(t, os.WriteFile(os.Getenv(EnvVarInputFile), []byte("\x1b[3;3R\r"), 0o600))
+
+	_, err := p.InputContext(context.Background())
+	require.NoError(t, err)
+
+	// the answer neither renders the prompt again nor asks for the position again, only Enter renders it
+	require.Equal(t, 2, strings.Count(out.String(), "> "))
+	require.Equal(t, 1, strings.Count(out.String(), requestCursorPosition))
+}
+
+func TestMouseExitChecker(t *testing.T) {
+	p := newFileInputPrompt(t)
+	out := &recordingWriter{}
+	require.NoError(t, OptionMouse()(p))
+	require.NoError(t, OptionWriter(out)(p))
+	require.NoError(t, OptionExecutorWithError(func(string) error { return nil })(p))
+	require.NoError(t, OptionSetExitCheckerOnInput(func(in string, breakline bool) bool { return breakline })(p))
+	p.Renderer().col = 80
+
+	go p.Accept()
+	require.NoError(t, p.RunContext(context.Background()))
+
+	// the prompt rendered after the executor, with the terminal out of raw mode, doesn't ask for the cursor position
+	disabled := strings.Index(out.String(), mouseOff)
+	require.GreaterOrEqual(t, disabled, 0)
+	require.NotContains(t, out.String()[disabled:], requestCursorPosition)
+}
+
+func TestMouseMode(t *testing.T) {
+	p := newFileInputPrompt(t)
+	require.NoError(t, OptionMouse()(p))
+	out := &recordingWriter{}
+	p.Renderer().out = out
+
+	go p.Accept()
+	_, err := p.InputContext(context.Background())
+	require.NoError(t, err)
+
+	enabled := strings.Index(out.String(), mouseOn)
+	disabled := strings.LastIndex(out.String(), mouseOff)
+	require.GreaterOrEqual(t, enabled, 0)
+	require.Greater(t, disabled, enabled)
+}
@@ -2,6 +2,7 @@ package prompt
 
 import (
 	"os"
+	"time"
 )
 
 const EnvVarInputFile = "GO_PROMPT_INPUT_FILE"
@@ -23,6 +24,9 @@ func OptionWriter(x ConsoleWriter) Option {
 	return func(p IPrompt) error {
 		registerConsoleWriter(x)
 		p.Renderer().out = x
+		if depth := p.Renderer().colorDepth; depth != 0 {
+			p.Renderer().setColorDepth(depth)
+		}
 		return nil
 	}
 }
@@ -70,7 +74,7 @@ func OptionLivePrefix(f func() (prefix string, useLivePrefix bool)) Option {
 // OptionPrefixTextColor change a text color of prefix string.
 func OptionPrefixTextColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().prefixTextColor = x
+		p.Renderer().theme.Prefix.Fg = x
 		return nil
 	}
 }
@@ -78,7 +82,7 @@ func OptionPrefixTextColor(x Color) Option {
 // OptionPrefixBackgroundColor to change a background color of prefix string.
 func OptionPrefixBackgroundColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().prefixBGColor = x
+		p.Renderer().theme.Prefix.Bg = x
 		return nil
 	}
 }
@@ -86,7 +90,7 @@ func OptionPrefixBackgroundColor(x Color) Option {
 // OptionInputTextColor to change a color of text which is input by user.
 func OptionInputTextColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().inputTextColor = x
+		p.Renderer().theme.Input.Fg = x
 		return nil
 	}
 }
@@ -94,7 +98,7 @@ func OptionInputTextColor(x Color) Option {
 // OptionInputBGColor to change a color of background which is input by user.
 func OptionInputBGColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().inputBGColor = x
+		p.Renderer().theme.Input.Bg = x
 		return nil
 	}
 }
@@ -102,7 +106,7 @@ func OptionInputBGColor(x Color) Option {
 // OptionPreviewSuggestionTextColor to change a text color which is completed.
 func OptionPreviewSuggestionTextColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().previewSuggestionTextColor = x
+		p.Renderer().theme.PreviewSuggestion.Fg = x
 		return nil
 	}
 }
@@ -110,7 +114,39 @@ func OptionPreviewSuggestionTextColor(x Color) Option {
 // OptionPreviewSuggestionBGColor to change a background color which is completed.
 func OptionPreviewSuggestionBGColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().previewSuggestionBGColor = x
+		p.Renderer().theme.PreviewSuggestion.Bg = x
+		return nil
+	}
+}
+
+// OptionSearchMatchTextColor to change a text color of the match of the history search.
+func OptionSearchMatchTextColor(x Color) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.SearchMatch.Fg = x
+		return nil
+	}
+}
+
+// OptionSearchMatchBGColor to change a background color of the match of the history search.
+func OptionSearchMatchBGColor(x Color) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.SearchMatch.Bg = x
+		return nil
+	}
+}
+
+// OptionAutoSuggestionTextColor to change a text color of the suggestion shown after the cursor.
+func OptionAutoSuggestionTextColor(x Color) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.AutoSuggestion.Fg = x
+		return nil
+	}
+}
+
+// OptionAutoSuggestionBGColor to change a background color of the suggestion shown after the cursor.
+func OptionAutoSuggestionBGColor(x Color) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.AutoSuggestion.Bg = x
 		return nil
 	}
 }
@@ -118,7 +154,7 @@ func OptionPreviewSuggestionBGColor(x Color) Option {
 // OptionSuggestionTextColor to change a text color in drop down suggestions.
 func OptionSuggestionTextColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().suggestionTextColor = x
+		p.Renderer().theme.Suggestion.Fg = x
 		return nil
 	}
 }
@@ -126,7 +162,7 @@ func OptionSuggestionTextColor(x Color) Option {
 // OptionSuggestionBGColor change a background color in drop down suggestions.
 func OptionSuggestionBGColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().suggestionBGColor = x
+		p.Renderer().theme.Suggestion.Bg = x
 		return nil
 	}
 }
@@ -142,7 +178,7 @@ func OptionDiagnosticsMaxRow(x uint16) Option {
 // OptionDiagnosticsTextColor to change a color of text which is input by user.
 func OptionDiagnosticsTextColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().diagnosticsTextColor = x
+		p.Renderer().theme.Diagnostics.Fg = x
 		return nil
 	}
 }
@@ -150,7 +186,7 @@ func OptionDiagnosticsTextColor(x Color) Option {
 // OptionDiagnosticsBGColor to change the color used to highlight the range returned in the diagnostics.
 func OptionDiagnosticsBGColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().diagnosticsBGColor = x
+		p.Renderer().theme.Diagnostics.Bg = x
 		return nil
 	}
 }
@@ -158,7 +194,7 @@ func OptionDiagnosticsBGColor(x Color) Option {
 // OptionDiagnosticsDetailsTextColor to change a color of text of the diagnostic details shown at the bottom.
 func OptionDiagnosticsDetailsTextColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().diagnosticsDetailsTextColor = x
+		p.Renderer().theme.DiagnosticsDetails.Fg = x
 		return nil
 	}
 }
@@ -166,7 +202,7 @@ func OptionDiagnosticsDetailsTextColor(x Color) Option {
 // OptionDiagnosticsDetailsTextColor to change a color of text of the diagnostic details shown at the bottom.
 func OptionDiagnosticsDetailsBGColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().diagnosticsDetailsBGColor = x
+		p.Renderer().theme.DiagnosticsDetails.Bg = x
 		return nil
 	}
 }
@@ -174,7 +210,7 @@ func OptionDiagnosticsDetailsBGColor(x Color) Option {
 // OptionSelectedSuggestionTextColor to change a text color for completed text which is selected inside suggestions drop down box.
 func OptionSelectedSuggestionTextColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().selectedSuggestionTextColor = x
+		p.Renderer().theme.SelectedSuggestion.Fg = x
 		return nil
 	}
 }
@@ -182,7 +218,7 @@ func OptionSelectedSuggestionTextColor(x Color) Option {
 // OptionSelectedSuggestionBGColor to change a background color for completed text which is selected inside suggestions drop down box.
 func OptionSelectedSuggestionBGColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().selectedSuggestionBGColor = x
+		p.Renderer().theme.SelectedSuggestion.Bg = x
 		return nil
 	}
 }
@@ -190,7 +226,7 @@ func OptionSelectedSuggestionBGColor(x Color) Option {
 // OptionDescriptionTextColor to change a background color of description text in drop down suggestions.
 func OptionDescriptionTextColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().descriptionTextColor = x
+		p.Renderer().theme.Description.Fg = x
 		return nil
 	}
 }
@@ -198,7 +234,7 @@ func OptionDescriptionTextColor(x Color) Option {
 // OptionDescriptionBGColor to change a background color of description text in drop down suggestions.
 func OptionDescriptionBGColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().descriptionBGColor = x
+		p.Renderer().theme.Description.Bg = x
 		return nil
 	}
 }
@@ -206,7 +242,7 @@ func OptionDescriptionBGColor(x Color) Option {
 // OptionSelectedDescriptionTextColor to change a text color of description which is selected inside suggestions drop down box.
 func OptionSelectedDescriptionTextColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().selectedDescriptionTextColor = x
+		p.Renderer().theme.SelectedDescription.Fg = x
 		return nil
 	}
 }
@@ -214,7 +250,7 @@ func OptionSelectedDescriptionTextColor(x Color) Option {
 // OptionSelectedDescriptionBGColor to change a background color of description which is selected inside suggestions drop down box.
 func OptionSelectedDescriptionBGColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().selectedDescriptionBGColor = x
+		p.Renderer().theme.SelectedDescription.Bg = x
 		return nil
 	}
 }
@@ -222,7 +258,7 @@ func OptionSelectedDescriptionBGColor(x Color) Option {
 // OptionScrollbarThumbColor to change a thumb color on scrollbar.
 func OptionScrollbarThumbColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().scrollbarThumbColor = x
+		p.Renderer().theme.ScrollbarThumb.Bg = x
 		return nil
 	}
 }
@@ -230,7 +266,158 @@ func OptionScrollbarThumbColor(x Color) Option {
 // OptionScrollbarBGColor to change a background color of scrollbar.
 func OptionScrollbarBGColor(x Color) Option {
 	return func(p IPrompt) error {
-		p.Renderer().scrollbarBGColor = x
+		p.Renderer().theme.Scrollbar.Bg = x
+		return nil
+	}
+}
+
+// OptionPrefixStyle to change the style of the prefix string.
+func OptionPrefixStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.Prefix = x
+		return nil
+	}
+}
+
+// OptionInputStyle to change the style of the text which is input by user. The styles of the lexer elements are applied over it.
+func OptionInputStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.Input = x
+		return nil
+	}
+}
+
+// OptionPreviewSuggestionStyle to change the style of the suggestion which is completed.
+func OptionPreviewSuggestionStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.PreviewSuggestion = x
+		return nil
+	}
+}
+
+// OptionSuggestionStyle to change the style of the suggestions in drop down box.
+func OptionSuggestionStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.Suggestion = x
+		return nil
+	}
+}
+
+// OptionSelectedSuggestionStyle to change the style of the suggestion which is selected inside suggestions drop down box.
+func OptionSelectedSuggestionStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.SelectedSuggestion = x
+		return nil
+	}
+}
+
+// OptionDescriptionStyle to change the style of the descriptions in drop down box.
+func OptionDescriptionStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.Description = x
+		return nil
+	}
+}
+
+// OptionSelectedDescriptionStyle to change the style of the description which is selected inside suggestions drop down box.
+func OptionSelectedDescriptionStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.SelectedDescription = x
+		return nil
+	}
+}
+
+// OptionScrollbarThumbStyle to change the style of the thumb on scrollbar.
+func OptionScrollbarThumbStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.ScrollbarThumb = x
+		return nil
+	}
+}
+
+// OptionScrollbarStyle to change the style of the scrollbar.
+func OptionScrollbarStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.Scrollbar = x
+		return nil
+	}
+}
+
+// OptionDiagnosticsStyle to change the style of the range returned in the diagnostics.
+func OptionDiagnosticsStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.Diagnostics = x
+		return nil
+	}
+}
+
+// OptionDiagnosticsDetailsStyle to change the style of the diagnostic details shown at the bottom.
+func OptionDiagnosticsDetailsStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.DiagnosticsDetails = x
+		return nil
+	}
+}
+
+// OptionSearchMatchStyle to change the style of the match of the history search.
+func OptionSearchMatchStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.SearchMatch = x
+		return nil
+	}
+}
+
+// OptionAutoSuggestionStyle to change the style of the suggestion shown after the cursor.
+func OptionAutoSuggestionStyle(x Style) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme.AutoSuggestion = x
+		return nil
+	}
+}
+
+// OptionTheme to change the styles of all the parts of the prompt, e.g. OptionTheme(ThemeLight).
+// The options changing a style or a color which come after it are applied over it.
+func OptionTheme(t Theme) Option {
+	return func(p IPrompt) error {
+		p.Renderer().theme = t
+		return nil
+	}
+}
+
+// OptionThemeFile to change the styles of all the parts of the prompt with the theme of a JSON file, see Theme.
+func OptionThemeFile(path string) Option {
+	return func(p IPrompt) error {
+		t, err := LoadThemeFile(path)
+		if err != nil {
+			return err
+		}
+		p.Renderer().theme = t
+		return nil
+	}
+}
+
+// OptionColorDepth overrides the colors detected by DetectColorDepth for the terminal. The colors of the styles are replaced
+// with the nearest ones it shows, and ColorDepthNone leaves them out.
+func OptionColorDepth(depth ColorDepth) Option {
+	return func(p IPrompt) error {
+		p.Renderer().setColorDepth(depth)
+		return nil
+	}
+}
+
+// OptionCompleterWithContext to set a completer which is called in the background and whose context is canceled
+// when the document changes. It replaces the completer passed to New.
+func OptionCompleterWithContext(fn CompleterWithContext) Option {
+	return func(p IPrompt) error {
+		p.CompletionManager().completer = fn
+		return nil
+	}
+}
+
+// OptionCompletionDebounce to wait until the user stops typing for the given duration before calling the completer.
+func OptionCompletionDebounce(x time.Duration) Option {
+	return func(p IPrompt) error {
+		p.CompletionManager().debounce = x
 		return nil
 	}
 }
@@ -252,6 +439,23 @@ func OptionHistory(x []string) Option {
 	}
 }
 
+// OptionHistoryFile loads the history from the file at path and appends each accepted input to it.
+// The file is locked while it's read or written, so that concurrent sessions can share it.
+func OptionHistoryFile(path string) Option {
+	return func(p IPrompt) error {
+		return p.History().LoadFile(path)
+	}
+}
+
+// OptionHistoryFileMaxEntries sets the number of entries kept in the history file, or in memory without a file.
+// The oldest entries are removed when it's exceeded. It's DefaultHistoryFileMaxEntries by default.
+func OptionHistoryFileMaxEntries(x int) Option {
+	return func(p IPrompt) error {
+		p.History().SetMaxFileEntries(x)
+		return nil
+	}
+}
+
 // OptionSwitchKeyBindMode set a key bind mode.
 func OptionSwitchKeyBindMode(m KeyBindMode) Option {
 	return func(p IPrompt) error {
@@ -268,6 +472,80 @@ func OptionCompletionOnDown() Option {
 	}
 }
 
+// OptionHistoryPrefixSearch makes Up and Down only go through the history entries starting with the text before the cursor,
+// like history-beginning-search-backward of zsh. The cursor stays after that text.
+func OptionHistoryPrefixSearch() Option {
+	return func(p IPrompt) error {
+		p.SetHistoryPrefixSearch(true)
+		return nil
+	}
+}
+
+// OptionAutoSuggestion shows the newest history entry starting with the text in grey after the cursor, like fish.
+// Right or End at the end of the line accepts it, Alt+F accepts its next word.
+func OptionAutoSuggestion() Option {
+	return func(p IPrompt) error {
+		p.SetAutoSuggester(HistoryAutoSuggester(p.History()))
+		return nil
+	}
+}
+
+// OptionAutoSuggester is like OptionAutoSuggestion, with the suggestions returned by fn instead of the history.
+func OptionAutoSuggester(fn AutoSuggester) Option {
+	return func(p IPrompt) error {
+		p.SetAutoSuggester(fn)
+		return nil
+	}
+}
+
+// OptionOnPaste sets a function transforming the pasted text before it's inserted.
+// The pasted text is inserted at once, so that the line breaks in it don't run the executor and tabs don't complete.
+func OptionOnPaste(fn OnPaste) Option {
+	return func(p IPrompt) error {
+		p.SetOnPaste(fn)
+		return nil
+	}
+}
+
+// OptionEscapeTimeout sets how long the prompt waits for the rest of an incomplete sequence, e.g. after ESC,
+// which is the Escape key when nothing follows it in time. It's DefaultEscapeTimeout by default.
+// A longer timeout helps over slow connections, a shorter one makes Escape quicker.
+func OptionEscapeTimeout(x time.Duration) Option {
+	return func(p IPrompt) error {
+		p.SetEscapeTimeout(x)
+		return nil
+	}
+}
+
+// OptionModifierKeys asks the terminal to report the modifiers of the keys with the kitty keyboard protocol,
+// or xterm's modifyOtherKeys, so that e.g. Shift+Enter, Ctrl+Shift+A or Alt+Up can be bound with KeyBind.Mods.
+// Shift+Enter breaks the line by default. The terminals which support neither protocol send the keys as before.
+func OptionModifierKeys() Option {
+	return func(p IPrompt) error {
+		p.SetModifierKeys(true)
+		return nil
+	}
+}
+
+// OptionConvertMeta decodes a byte with the eighth bit set which isn't part of a UTF-8 character as a Meta key,
+// like convert-meta of readline: 0xe4 is Alt+D. It's for the terminals sending the Alt keys this way,
+// the bytes are inserted as they are by default.
+func OptionConvertMeta() Option {
+	return func(p IPrompt) error {
+		p.SetConvertMeta(true)
+		return nil
+	}
+}
+
+// OptionMouse enables the mouse in the SGR 1006 format: clicking a suggestion selects it, the wheel scrolls
+// the completion menu and clicking the input moves the cursor there. The terminal doesn't scroll with the wheel then.
+func OptionMouse() Option {
+	return func(p IPrompt) error {
+		p.SetMouse(true)
+		return nil
+	}
+}
+
 // SwitchKeyBindMode to set a key bind mode.
 // Deprecated: Please use OptionSwitchKeyBindMode.
 var SwitchKeyBindMode = OptionSwitchKeyBindMode
@@ -329,48 +607,66 @@ func OptionSetStatementTerminator(fn StatementTerminatorCb) Option {
 	}
 }
 
+// OptionHistoryStore adds the entries of the store to the history, and appends each accepted input to it.
+// See MemoryHistoryStore and FileHistoryStore.
+func OptionHistoryStore(store HistoryStore) Option {
+	return func(p IPrompt) error {
+		return p.History().SetStore(store)
+	}
+}
+
+// OptionHistorySessionID sets the session ID recorded with the history entries, it's random by default.
+func OptionHistorySessionID(id string) Option {
+	return func(p IPrompt) error {
+		p.History().SetSessionID(id)
+		return nil
+	}
+}
+
+// OptionExecutorWithError sets an executor returning an error instead of the Executor passed to New.
+// The entries of the inputs it fails are recorded as failed in the history.
+func OptionExecutorWithError(executor ExecutorWithError) Option {
+	return func(p IPrompt) error {
+		p.SetExecutorWithError(executor)
+		return nil
+	}
+}
+
+// OptionSignalHandler to decide what to do with SIGINT, SIGTERM and SIGQUIT.
+// By default the prompt restores the terminal and Run/Input return, and the signals caught while the executor runs
+// are forwarded to it (see DefaultSignalHandler).
+func OptionSignalHandler(fn SignalHandler) Option {
+	return func(p IPrompt) error {
+		p.SetSignalHandler(fn)
+		return nil
+	}
+}
+
 // New returns a Prompt with powerful auto-completion.
+// The terminal is opened once the options are applied, unless OptionParser sets the ConsoleParser.
+// When an option fails, the ConsoleParser it has set is closed like by Close.
 func New(executor Executor, completer Completer, opts ...Option) (IPrompt, error) {
 	defaultWriter := NewStdoutWriter()
 	registerConsoleWriter(defaultWriter)
 
-	inputParser, err := getInputParser()
-	if err != nil {
-		return nil, err
-	}
 	pt := &Prompt{
-		in: inputParser,
 		renderer: &Render{
-			prefix:                       "> ",
-			out:                          defaultWriter,
-			livePrefixCallback:           func() (string, bool) { return "", false },
-			prefixTextColor:              Blue,
-			prefixBGColor:                DefaultColor,
-			inputTextColor:               DefaultColor,
-			inputBGColor:                 DefaultColor,
-			previewSuggestionTextColor:   Green,
-			previewSuggestionBGColor:     DefaultColor,
-			suggestionTextColor:          White,
-			suggestionBGColor:            Cyan,
-			diagnosticsMaxRow:            6,
-			diagnosticsTextColor:         White,
-			diagnosticsBGColor:           Red,
-			diagnosticsDetailsTextColor:  Red,
-			selectedSuggestionTextColor:  Black,
-			selectedSuggestionBGColor:    Turquoise,
-			descriptionTextColor:         Black,
-			descriptionBGColor:           Turquoise,
-			selectedDescriptionTextColor: White,
-			selectedDescriptionBGColor:   Cyan,
-			scrollbarThumbColor:          DarkGray,
-			scrollbarBGColor:             Cyan,
+			prefix:             "> ",
+			out:                defaultWriter,
+			livePrefixCallback: func() (string, bool) { return "", false },
+			theme:              ThemeDark,
+			diagnosticsMaxRow:  6,
 		},
-		buf:         NewBuffer(),
-		executor:    executor,
-		history:     NewHistory(),
-		lexer:       NewLexer(),
-		completion:  NewCompletionManager(completer, 6),
-		keyBindMode: EmacsKeyBind, // All the above assume that bash is running in the default Emacs setting
+		buf:           NewBuffer(),
+		killRing:      NewKillRing(DefaultKillRingSize),
+		executor:      executor,
+		history:       NewHistory(),
+		lexer:         NewLexer(),
+		completion:    NewCompletionManager(completer, 6),
+		keyBindMode:   EmacsKeyBind, // All the above assume that bash is running in the default Emacs setting
+		escapeTimeout: DefaultEscapeTimeout,
+		signals:       make(chan os.Signal, 1),
+		actionCh:      make(chan struct{}, 1),
 		statementTerminatorCb: func(lastKeyStroke Key, buffer *Buffer) bool {
 			// terminate statement on enter which is either \r or \n, based on OS
 			if lastKeyStroke == ControlM || lastKeyStroke == Enter {
@@ -382,8 +678,16 @@ func New(executor Executor, completer Completer, opts ...Option) (IPrompt, error
 
 	for _, opt := range opts {
 		if err := opt(pt); err != nil {
+			_ = pt.Close()
+			return nil, err
+		}
+	}
+	if pt.in == nil {
+		inputParser, err := getInputParser()
+		if err != nil {
 			return nil, err
 		}
+		pt.in = inputParser
 	}
 	return pt, nil
 }
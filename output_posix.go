@@ -3,7 +3,10 @@
 package prompt
 
 import (
+	"os"
 	"syscall"
+
+	"github.com/pkg/term/termios"
 )
 
 const flushMaxRetryCount = 3
@@ -49,19 +52,21 @@ var (
 )
 
 // NewStdoutWriter returns ConsoleWriter object to write to stdout.
-// This generates VT100 escape sequences because almost terminal emulators
-// in POSIX OS built on top of a VT100 specification.
+// This generates the escape sequences of the terminfo entry of $TERM,
+// or VT100 escape sequences when there is none, with the colors detected by DetectColorDepth.
 func NewStdoutWriter() ConsoleWriter {
-	return &PosixWriter{
-		fd: syscall.Stdout,
-	}
+	return newPosixWriter(os.Stdout)
 }
 
 // NewStderrWriter returns ConsoleWriter object to write to stderr.
-// This generates VT100 escape sequences because almost terminal emulators
-// in POSIX OS built on top of a VT100 specification.
+// This generates the escape sequences of the terminfo entry of $TERM,
+// or VT100 escape sequences when there is none, with the colors detected by DetectColorDepth.
 func NewStderrWriter() ConsoleWriter {
-	return &PosixWriter{
-		fd: syscall.Stderr,
-	}
+	return newPosixWriter(os.Stderr)
+}
+
+// isTerminal returns whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	_, err := termios.Tcgetattr(f.Fd())
+	return err == nil
 }
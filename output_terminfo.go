@@ -0,0 +1,344 @@
+//go:build !windows
+
+package prompt
+
+import (
+	"os"
+	"strings"
+
+	"github.com/confluentinc/go-prompt/internal/terminfo"
+)
+
+// TerminfoWriter is a ConsoleWriter implementation for POSIX environment which outputs the escape sequences
+// described by the terminfo database for the terminal, and the VT100 ones when the terminal's entry lacks a capability.
+type TerminfoWriter struct {
+	PosixWriter
+	info *terminfo.Terminfo
+}
+
+// NewTerminfoWriter returns a TerminfoWriter writing to the file descriptor fd, with the terminfo entry of term
+// and the colors it tells.
+func NewTerminfoWriter(fd int, term string) (*TerminfoWriter, error) {
+	info, err := terminfo.Load(term)
+	if err != nil {
+		return nil, err
+	}
+	w := &TerminfoWriter{PosixWriter: PosixWriter{fd: fd}, info: info}
+	w.colorDepth = terminfoColorDepth(info)
+	return w, nil
+}
+
+// terminfoColorDepth returns the color depth of the terminal, which has the 24-bit colors when its entry
+// has the RGB or the Tc extended capability or the colors of the direct color entries, e.g. xterm-direct.
+func terminfoColorDepth(info *terminfo.Terminfo) ColorDepth {
+	colors := info.Numbers["colors"]
+	_, hasColors := info.Strings["setaf"]
+	switch {
+	case colors >= 1<<24 || info.Bools["RGB"] || info.Bools["Tc"]:
+		return ColorDepthTrueColor
+	case colors >= 256:
+		return ColorDepth256
+	case colors >= 8 && hasColors:
+		return ColorDepth16
+	}
+	return ColorDepthNone
+}
+
+// newPosixWriter returns a TerminfoWriter when there is a terminfo entry for $TERM, and a PosixWriter otherwise,
+// writing to f with the colors detected by DetectColorDepth, and without display attributes when f is a plain output.
+func newPosixWriter(f *os.File) ConsoleWriter {
+	fd := int(f.Fd())
+	if w, err := NewTerminfoWriter(fd, os.Getenv("TERM")); err == nil {
+		w.colorDepth = detectColorDepth(os.Getenv, isTerminal(f), w.colorDepth)
+		w.plain = isPlainOutput(f)
+		return w
+	}
+	w := &PosixWriter{fd: fd}
+	w.colorDepth = detectColorDepth(os.Getenv, isTerminal(f), 0)
+	w.plain = isPlainOutput(f)
+	return w
+}
+
+// terminalColorDepth returns the colors of the terminal according to its terminfo entry, 0 when there is none.
+func terminalColorDepth(term string) ColorDepth {
+	info, err := terminfo.Load(term)
+	if err != nil {
+		return 0
+	}
+	return terminfoColorDepth(info)
+}
+
+// writeCap writes the string capability with its parameters, and returns false when the terminal doesn't have it.
+func (w *TerminfoWriter) writeCap(name string, params ...any) bool {
+	s, ok := w.info.Strings[name]
+	if !ok {
+		return false
+	}
+	w.WriteRawStr(terminfo.Tparm(s, params...))
+	return true
+}
+
+/* Erase */
+
+// EraseScreen erases the screen with the background colour and moves the cursor to home.
+func (w *TerminfoWriter) EraseScreen() {
+	if !w.writeCap("clear") {
+		w.VT100Writer.EraseScreen()
+	}
+}
+
+// EraseDown erases the screen from the current line down to the bottom of the screen.
+func (w *TerminfoWriter) EraseDown() {
+	if !w.writeCap("ed") {
+		w.VT100Writer.EraseDown()
+	}
+}
+
+// EraseStartOfLine erases from the current cursor position to the start of the current line.
+func (w *TerminfoWriter) EraseStartOfLine() {
+	if !w.writeCap("el1") {
+		w.VT100Writer.EraseStartOfLine()
+	}
+}
+
+// EraseEndOfLine erases from the current cursor position to the end of the current line.
+func (w *TerminfoWriter) EraseEndOfLine() {
+	if !w.writeCap("el") {
+		w.VT100Writer.EraseEndOfLine()
+	}
+}
+
+/* Cursor */
+
+// ShowCursor shows the cursor in its normal appearance.
+func (w *TerminfoWriter) ShowCursor() {
+	if !w.writeCap("cnorm") {
+		w.VT100Writer.ShowCursor()
+	}
+}
+
+// HideCursor hides cursor.
+func (w *TerminfoWriter) HideCursor() {
+	if !w.writeCap("civis") {
+		w.VT100Writer.HideCursor()
+	}
+}
+
+// CursorGoTo sets the cursor position where subsequent text will begin. Like the VT100 sequence,
+// the top left cell is 1, 1 and 0, 0 stands for it.
+func (w *TerminfoWriter) CursorGoTo(row, col int) {
+	if row == 0 && col == 0 && w.writeCap("home") {
+		return
+	}
+	if !w.writeCap("cup", max(row-1, 0), max(col-1, 0)) {
+		w.VT100Writer.CursorGoTo(row, col)
+	}
+}
+
+// CursorUp moves the cursor up by 'n' rows; the default count is 1.
+func (w *TerminfoWriter) CursorUp(n int) {
+	if n < 0 {
+		w.CursorDown(-n)
+	} else if n > 0 && !w.writeCap("cuu", n) {
+		w.VT100Writer.CursorUp(n)
+	}
+}
+
+// CursorDown moves the cursor down by 'n' rows; the default count is 1.
+func (w *TerminfoWriter) CursorDown(n int) {
+	if n < 0 {
+		w.CursorUp(-n)
+	} else if n > 0 && !w.writeCap("cud", n) {
+		w.VT100Writer.CursorDown(n)
+	}
+}
+
+// CursorForward moves the cursor forward by 'n' columns; the default count is 1.
+func (w *TerminfoWriter) CursorForward(n int) {
+	if n < 0 {
+		w.CursorBackward(-n)
+	} else if n > 0 && !w.writeCap("cuf", n) {
+		w.VT100Writer.CursorForward(n)
+	}
+}
+
+// CursorBackward moves the cursor backward by 'n' columns; the default count is 1.
+func (w *TerminfoWriter) CursorBackward(n int) {
+	if n < 0 {
+		w.CursorForward(-n)
+	} else if n > 0 && !w.writeCap("cub", n) {
+		w.VT100Writer.CursorBackward(n)
+	}
+}
+
+// AskForCPR asks for a cursor position report (CPR).
+func (w *TerminfoWriter) AskForCPR() {
+	if !w.writeCap("u7") {
+		w.VT100Writer.AskForCPR()
+	}
+}
+
+// SaveCursor saves current cursor position.
+func (w *TerminfoWriter) SaveCursor() {
+	if !w.writeCap("sc") {
+		w.VT100Writer.SaveCursor()
+	}
+}
+
+// UnSaveCursor restores cursor position after a Save Cursor.
+func (w *TerminfoWriter) UnSaveCursor() {
+	if !w.writeCap("rc") {
+		w.VT100Writer.UnSaveCursor()
+	}
+}
+
+/* Scrolling */
+
+// ScrollDown scrolls display down one line.
+func (w *TerminfoWriter) ScrollDown() {
+	if !w.writeCap("ind") {
+		w.VT100Writer.ScrollDown()
+	}
+}
+
+// ScrollUp scroll display up one line.
+func (w *TerminfoWriter) ScrollUp() {
+	if !w.writeCap("ri") {
+		w.VT100Writer.ScrollUp()
+	}
+}
+
+/* Title */
+
+// SetTitle sets a title of terminal window, with the status line capabilities when the terminal has them.
+func (w *TerminfoWriter) SetTitle(title string) {
+	if !w.hasCaps("tsl", "fsl") {
+		w.VT100Writer.SetTitle(title)
+		return
+	}
+	title = strings.Map(func(r rune) rune {
+		if r == 0x13 || r == 0x07 {
+			return -1
+		}
+		return r
+	}, title)
+	w.writeCap("tsl", 0)
+	w.WriteRawStr(title)
+	w.writeCap("fsl")
+}
+
+// ClearTitle clears a title of terminal window.
+func (w *TerminfoWriter) ClearTitle() {
+	if !w.hasCaps("tsl", "fsl") {
+		w.VT100Writer.ClearTitle()
+		return
+	}
+	w.writeCap("tsl", 0)
+	w.writeCap("fsl")
+}
+
+/* Font */
+
+// SetColor sets text and background colors. and specify whether text is bold.
+func (w *TerminfoWriter) SetColor(fg, bg Color, bold bool) {
+	if bold {
+		w.SetDisplayAttributes(fg, bg, DisplayBold)
+	} else {
+		w.SetDisplayAttributes(fg, bg, DisplayReset)
+	}
+}
+
+// terminfoAttributes are the capabilities of the display attributes.
+var terminfoAttributes = map[DisplayAttribute]string{
+	DisplayReset:        "sgr0",
+	DisplayBold:         "bold",
+	DisplayLowIntensity: "dim",
+	DisplayItalic:       "sitm",
+	DisplayUnderline:    "smul",
+	DisplayBlink:        "blink",
+	DisplayRapidBlink:   "blink",
+	DisplayReverse:      "rev",
+	DisplayInvisible:    "invis",
+	DisplayCrossedOut:   "smxx",
+}
+
+// SetDisplayAttributes sets the display attributes and the colors with the capabilities of the terminal.
+// The attributes the terminal doesn't have are left out, and the colors are replaced with the nearest ones
+// the terminal shows, e.g. the high intensity colors with the low intensity ones when it has 8 colors.
+// Without the color capabilities, the VT100 sequence is written. The colors are left out with ColorDepthNone.
+func (w *TerminfoWriter) SetDisplayAttributes(fg, bg Color, attrs ...DisplayAttribute) {
+	if w.plain {
+		return
+	}
+	if !w.hasCaps("sgr0", "setaf", "setab") {
+		w.VT100Writer.SetDisplayAttributes(fg, bg, attrs...)
+		return
+	}
+	fg, bg = fg.degrade(w.colorDepth), bg.degrade(w.colorDepth)
+	if _, ok := foregroundANSIColors[fg]; !ok && fg&(colorIndexed|colorRGB) == 0 {
+		fg = DefaultColor
+	}
+	if _, ok := backgroundANSIColors[bg]; !ok && bg&(colorIndexed|colorRGB) == 0 {
+		bg = DefaultColor
+	}
+	for _, a := range attrs {
+		if name, ok := terminfoAttributes[a]; ok {
+			w.writeCap(name)
+		}
+	}
+	if w.colorDepth == ColorDepthNone {
+		return
+	}
+	if fg == DefaultColor || bg == DefaultColor {
+		if !w.writeCap("op") {
+			w.VT100Writer.SetDisplayAttributes(DefaultColor, DefaultColor)
+		}
+	}
+	w.setColor("setaf", fg, false)
+	w.setColor("setab", bg, true)
+}
+
+// setColor sets the text or the background color c with the capability, setaf or setab, which takes the index
+// of a color of the palette of the terminal. The 24-bit colors, and the colors of the palette of the direct color entries,
+// whose capability takes a 24-bit color instead, are set with the VT100 sequence.
+func (w *TerminfoWriter) setColor(capName string, c Color, background bool) {
+	colors := w.info.Numbers["colors"]
+	switch {
+	case c == DefaultColor:
+	case c&colorRGB != 0:
+		w.writeSGR(colorParameters(c, background))
+	case c&colorIndexed != 0:
+		if colors > 256 {
+			w.writeSGR(colorParameters(c, background))
+		} else {
+			w.writeCap(capName, int(uint8(c)))
+		}
+	default:
+		i := int(c - Black)
+		if colors > 256 && i >= 8 {
+			w.writeSGR(colorParameters(c, background))
+		} else {
+			if colors > 0 && i >= colors {
+				i -= 8
+			}
+			w.writeCap(capName, i)
+		}
+	}
+}
+
+func (w *TerminfoWriter) writeSGR(params []byte) {
+	w.WriteRaw([]byte{0x1b, '['})
+	w.WriteRaw(params)
+	w.WriteRaw([]byte{'m'})
+}
+
+func (w *TerminfoWriter) hasCaps(names ...string) bool {
+	for _, n := range names {
+		if _, ok := w.info.Strings[n]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var _ ConsoleWriter = &TerminfoWriter{}
@@ -0,0 +1,178 @@
+//go:build !windows
+
+package prompt
+
+import (
+	"testing"
+
+	"github.com/confluentinc/go-prompt/internal/terminfo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerminfoWriter(t *testing.T) {
+	linux := &terminfo.Terminfo{
+		Names:   []string{"linux"},
+		Numbers: map[string]int{"colors": 8},
+		Strings: map[string]string{
+			"clear": "\x1b[H\x1b[J",
+			"cnorm": "\x1b[?25h\x1b[?0c",
+			"cub":   "\x1b[%p1%dD",
+			"cud":   "\x1b[%p1%dB",
+			"cup":   "\x1b[%i%p1%d;%p2%dH",
+			"home":  "\x1b[H",
+			"op":    "\x1b[39;49m",
+			"sc":    "\x1b7",
+			"setab": "\x1b[4%p1%dm",
+			"setaf": "\x1b[3%p1%dm",
+			"sgr0":  "\x1b[m\x0f",
+			"bold":  "\x1b[1m",
+		},
+	}
+	scenarios := []struct {
+		name  string
+		info  *terminfo.Terminfo
+		write func(w ConsoleWriter)
+		want  string
+	}{
+		{name: "erase screen", info: linux, write: func(w ConsoleWriter) { w.EraseScreen() }, want: "\x1b[H\x1b[J"},
+		{name: "show cursor", info: linux, write: func(w ConsoleWriter) { w.ShowCursor() }, want: "\x1b[?25h\x1b[?0c"},
+		{name: "save cursor", info: linux, write: func(w ConsoleWriter) { w.SaveCursor() }, want: "\x1b7"},
+		{name: "go to", info: linux, write: func(w ConsoleWriter) { w.CursorGoTo(3, 10) }, want: "\x1b[3;10H"},
+		{name: "go to home", info: linux, write: func(w ConsoleWriter) { w.CursorGoTo(0, 0) }, want: "\x1b[H"},
+		{name: "cursor down", info: linux, write: func(w ConsoleWriter) { w.CursorDown(2) }, want: "\x1b[2B"},
+		{name: "cursor up negative", info: linux, write: func(w ConsoleWriter) { w.CursorUp(-2) }, want: "\x1b[2B"},
+		{name: "cursor forward negative", info: linux, write: func(w ConsoleWriter) { w.CursorForward(-4) }, want: "\x1b[4D"},
+		{name: "cursor zero", info: linux, write: func(w ConsoleWriter) { w.CursorBackward(0) }, want: ""},
+		{name: "missing capability", info: linux, write: func(w ConsoleWriter) { w.CursorUp(2) }, want: "\x1b[2A"},
+		{name: "missing capability erase", info: linux, write: func(w ConsoleWriter) { w.EraseEndOfLine() }, want: "\x1b[K"},
+		{name: "title", info: linux, write: func(w ConsoleWriter) { w.SetTitle("title") }, want: "\x1b]2;title\x07"},
+		{
+			name:  "color",
+			info:  linux,
+			write: func(w ConsoleWriter) { w.SetColor(DarkRed, DefaultColor, true) },
+			want:  "\x1b[1m\x1b[39;49m\x1b[31m",
+		},
+		{
+			name:  "high intensity color with 8 colors",
+			info:  linux,
+			write: func(w ConsoleWriter) { w.SetColor(White, Blue, false) },
+			want:  "\x1b[m\x0f\x1b[37m\x1b[44m",
+		},
+		{
+			name:  "without colors",
+			info:  &terminfo.Terminfo{Strings: map[string]string{"sgr0": "\x1b[m"}},
+			write: func(w ConsoleWriter) { w.SetColor(Red, DefaultColor, false) },
+			want:  "\x1b[0m",
+		},
+		{
+			name: "status line title",
+			info: &terminfo.Terminfo{Strings: map[string]string{"tsl": "\x1b]0;", "fsl": "\x07"}},
+			write: func(w ConsoleWriter) {
+				w.SetTitle("a\x07b")
+				w.ClearTitle()
+			},
+			want: "\x1b]0;ab\x07\x1b]0;\x07",
+		},
+	}
+
+	xterm256 := &terminfo.Terminfo{
+		Names:   []string{"xterm-256color"},
+		Numbers: map[string]int{"colors": 256},
+		Strings: map[string]string{
+			"op":    "\x1b[39;49m",
+			"setab": "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+			"setaf": "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+			"sgr0":  "\x1b(B\x1b[m",
+		},
+	}
+	direct := &terminfo.Terminfo{
+		Names:   []string{"xterm-direct"},
+		Numbers: map[string]int{"colors": 1 << 24},
+		Strings: map[string]string{
+			"op":    "\x1b[39;49m",
+			"setab": "\x1b[%?%p1%{8}%<%t4%p1%d%e48:2::%p1%{65536}%/%d:%p1%{256}%/%{255}%&%d:%p1%{255}%&%d%;m",
+			"setaf": "\x1b[%?%p1%{8}%<%t3%p1%d%e38:2::%p1%{65536}%/%d:%p1%{256}%/%{255}%&%d:%p1%{255}%&%d%;m",
+			"sgr0":  "\x1b(B\x1b[m",
+		},
+	}
+	scenarios = append(scenarios, []struct {
+		name  string
+		info  *terminfo.Terminfo
+		write func(w ConsoleWriter)
+		want  string
+	}{
+		{
+			name:  "256 colors",
+			info:  xterm256,
+			write: func(w ConsoleWriter) { w.SetColor(Color256(208), Hex("#ff8700"), false) },
+			want:  "\x1b(B\x1b[m\x1b[38;5;208m\x1b[48;5;208m",
+		},
+		{
+			name:  "256 colors with 8 colors",
+			info:  linux,
+			write: func(w ConsoleWriter) { w.SetColor(Color256(208), RGB(0, 0, 0xee), false) },
+			want:  "\x1b[m\x0f\x1b[33m\x1b[44m",
+		},
+		{
+			name:  "direct colors",
+			info:  direct,
+			write: func(w ConsoleWriter) { w.SetColor(Red, Color256(208), false) },
+			want:  "\x1b(B\x1b[m\x1b[91m\x1b[48;5;208m",
+		},
+	}...)
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			w := &TerminfoWriter{info: s.info}
+			w.colorDepth = terminfoColorDepth(s.info)
+			s.write(w)
+			require.Equal(t, s.want, string(w.buffer))
+		})
+	}
+}
+
+func TestTerminfoColorDepth(t *testing.T) {
+	setaf := map[string]string{"setaf": "\x1b[3%p1%dm"}
+	require.Equal(t, ColorDepthNone, terminfoColorDepth(&terminfo.Terminfo{Strings: map[string]string{}}))
+	require.Equal(t, ColorDepthNone, terminfoColorDepth(&terminfo.Terminfo{Numbers: map[string]int{"colors": 8}}))
+	require.Equal(t, ColorDepth16, terminfoColorDepth(&terminfo.Terminfo{Numbers: map[string]int{"colors": 8}, Strings: setaf}))
+	require.Equal(t, ColorDepth256, terminfoColorDepth(&terminfo.Terminfo{Numbers: map[string]int{"colors": 256}, Strings: setaf}))
+	require.Equal(t, ColorDepthTrueColor, terminfoColorDepth(&terminfo.Terminfo{Numbers: map[string]int{"colors": 256}, Bools: map[string]bool{"Tc": true}}))
+	require.Equal(t, ColorDepthTrueColor, terminfoColorDepth(&terminfo.Terminfo{Numbers: map[string]int{"colors": 1 << 24}, Strings: setaf}))
+
+	w := &TerminfoWriter{info: &terminfo.Terminfo{Numbers: map[string]int{"colors": 8}, Strings: map[string]string{
+		"setaf": "\x1b[3%p1%dm", "setab": "\x1b[4%p1%dm", "sgr0": "\x1b[m", "bold": "\x1b[1m", "op": "\x1b[39;49m",
+	}}}
+	w.SetColorDepth(ColorDepthNone)
+	w.SetColor(Red, Blue, true)
+	require.Equal(t, "\x1b[1m", string(w.buffer))
+}
+
+func TestNewStdoutWriter(t *testing.T) {
+	// the output of the tests isn't a terminal, so that no display attributes are written
+	setStyles := func(w ConsoleWriter) {
+		w.SetColor(Red, Blue, true)
+		w.SetDisplayAttributes(Hex("#ff8800"), DefaultColor, DisplayReset, DisplayUnderline)
+		w.SetDisplayAttributes(DefaultColor, DefaultColor)
+	}
+	if _, err := terminfo.Load("linux"); err == nil {
+		t.Setenv("TERM", "linux")
+		require.IsType(t, &TerminfoWriter{}, NewStdoutWriter())
+		tw := NewStderrWriter().(*TerminfoWriter)
+		setStyles(tw)
+		require.Empty(t, tw.buffer)
+	}
+
+	t.Setenv("TERMINFO", t.TempDir())
+	t.Setenv("TERMINFO_DIRS", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TERM", "xterm")
+	require.IsType(t, &PosixWriter{}, NewStdoutWriter())
+	pw := NewStderrWriter().(*PosixWriter)
+	require.Equal(t, ColorDepthNone, pw.colorDepth)
+	setStyles(pw)
+	require.Empty(t, pw.buffer)
+
+	_, err := NewTerminfoWriter(1, "xterm")
+	require.ErrorIs(t, err, terminfo.ErrNotFound)
+}
@@ -8,6 +8,10 @@ import (
 // VT100Writer generates VT100 escape sequences.
 type VT100Writer struct {
 	buffer []byte
+	// colorDepth is the colors of the terminal, see SetColorDepth.
+	colorDepth ColorDepth
+	// plain leaves out the display attributes as well as the colors, when the output isn't a terminal showing them.
+	plain bool
 }
 
 // WriteRaw to write raw byte array
@@ -219,32 +223,37 @@ func (w *VT100Writer) SetColor(fg, bg Color, bold bool) {
 	}
 }
 
-// SetDisplayAttributes to set VT100 display attributes.
+// SetDisplayAttributes to set VT100 display attributes. The 256 colors and the 24-bit colors are written
+// with the 38;5 and the 38;2 parameters, and the colors are left out with ColorDepthNone.
+// Nothing is written when the output is plain, see isPlainOutput.
 func (w *VT100Writer) SetDisplayAttributes(fg, bg Color, attrs ...DisplayAttribute) {
-	w.WriteRaw([]byte{0x1b, '['}) // control sequence introducer
-	defer w.WriteRaw([]byte{'m'}) // final character
-
-	var separator byte = ';'
+	if w.plain {
+		return
+	}
+	var params [][]byte
 	for i := range attrs {
-		p, ok := displayAttributeParameters[attrs[i]]
-		if !ok {
-			continue
+		if p, ok := displayAttributeParameters[attrs[i]]; ok {
+			params = append(params, p)
 		}
-		w.WriteRaw(p)
-		w.WriteRaw([]byte{separator})
 	}
-
-	f, ok := foregroundANSIColors[fg]
-	if !ok {
-		f = foregroundANSIColors[DefaultColor]
+	if w.colorDepth != ColorDepthNone {
+		params = append(params, colorParameters(fg.degrade(w.colorDepth), false), colorParameters(bg.degrade(w.colorDepth), true))
 	}
-	w.WriteRaw(f)
-	w.WriteRaw([]byte{separator})
-	b, ok := backgroundANSIColors[bg]
-	if !ok {
-		b = backgroundANSIColors[DefaultColor]
+	if len(params) == 0 {
+		return
 	}
-	w.WriteRaw(b)
+
+	w.WriteRaw([]byte{0x1b, '['}) // control sequence introducer
+	w.WriteRaw(bytes.Join(params, []byte{';'}))
+	w.WriteRaw([]byte{'m'}) // final character
+}
+
+// SetColorDepth sets the colors of the terminal. The colors it can't show are replaced with the nearest ones,
+// and ColorDepthNone leaves out the colors. The colors are written as they are with a zero depth.
+// It overrides the detection of a plain output, so that the display attributes are written again.
+func (w *VT100Writer) SetColorDepth(depth ColorDepth) {
+	w.colorDepth = depth
+	w.plain = false
 }
 
 var displayAttributeParameters = map[DisplayAttribute][]byte{
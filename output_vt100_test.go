@@ -3,6 +3,8 @@ package prompt
 import (
 	"bytes"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestVT100WriterWrite(t *testing.T) {
@@ -79,3 +81,43 @@ func TestVT100WriterWriteRawStr(t *testing.T) {
 		}
 	}
 }
+
+func TestVT100WriterSetDisplayAttributes(t *testing.T) {
+	scenarioTable := []struct {
+		fg, bg   Color
+		depth    ColorDepth
+		attrs    []DisplayAttribute
+		expected string
+	}{
+		{fg: Red, bg: DefaultColor, attrs: []DisplayAttribute{DisplayBold}, expected: "\x1b[1;91;49m"},
+		{fg: Color256(208), bg: Hex("#102030"), expected: "\x1b[38;5;208;48;2;16;32;48m"},
+		{fg: Color256(208), bg: Hex("#102030"), depth: ColorDepth256, expected: "\x1b[38;5;208;48;5;234m"},
+		{fg: Color256(208), bg: Hex("#102030"), depth: ColorDepth16, expected: "\x1b[33;40m"},
+	}
+
+	for _, s := range scenarioTable {
+		pw := &VT100Writer{colorDepth: s.depth}
+		pw.SetDisplayAttributes(s.fg, s.bg, s.attrs...)
+		require.Equal(t, s.expected, string(pw.buffer))
+	}
+}
+
+func TestVT100WriterSetColorDepthNone(t *testing.T) {
+	pw := &VT100Writer{}
+	pw.SetColorDepth(ColorDepthNone)
+	pw.SetColor(Red, Blue, true)
+	// nothing to set
+	pw.SetDisplayAttributes(Hex("#ff8800"), DefaultColor)
+	require.Equal(t, "\x1b[1m", string(pw.buffer))
+}
+
+func TestVT100WriterPlain(t *testing.T) {
+	pw := &VT100Writer{plain: true}
+	pw.SetColor(Red, Blue, true)
+	pw.SetDisplayAttributes(Hex("#ff8800"), DefaultColor, DisplayReset, DisplayUnderline)
+	require.Empty(t, pw.buffer)
+
+	pw.SetColorDepth(ColorDepthNone)
+	pw.SetColor(Red, Blue, true)
+	require.Equal(t, "\x1b[1m", string(pw.buffer))
+}
@@ -4,6 +4,8 @@ package prompt
 
 import (
 	"io"
+	"os"
+	"syscall"
 
 	colorable "github.com/mattn/go-colorable"
 )
@@ -33,17 +35,37 @@ var (
 )
 
 // NewStdoutWriter returns ConsoleWriter object to write to stdout.
-// This generates win32 control sequences.
+// This generates win32 control sequences, with the colors detected by DetectColorDepth.
 func NewStdoutWriter() ConsoleWriter {
-	return &WindowsWriter{
+	w := &WindowsWriter{
 		out: colorable.NewColorableStdout(),
 	}
+	w.colorDepth = DetectColorDepth(os.Stdout)
+	w.plain = isPlainOutput(os.Stdout)
+	return w
 }
 
 // NewStderrWriter returns ConsoleWriter object to write to stderr.
-// This generates win32 control sequences.
+// This generates win32 control sequences, with the colors detected by DetectColorDepth.
 func NewStderrWriter() ConsoleWriter {
-	return &WindowsWriter{
+	w := &WindowsWriter{
 		out: colorable.NewColorableStderr(),
 	}
+	w.colorDepth = DetectColorDepth(os.Stderr)
+	w.plain = isPlainOutput(os.Stderr)
+	return w
+}
+
+// isTerminal returns whether f is a console.
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
+
+// terminalColorDepth returns the 24-bit colors in Windows Terminal, and 0 otherwise.
+func terminalColorDepth(term string) ColorDepth {
+	if os.Getenv("WT_SESSION") != "" {
+		return ColorDepthTrueColor
+	}
+	return 0
 }
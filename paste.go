@@ -0,0 +1,35 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+)
+
+var (
+	// pasteStart and pasteEnd surround the pasted text in the bracketed paste mode.
+	pasteStart = []byte("\x1b[200~")
+	pasteEnd   = []byte("\x1b[201~")
+)
+
+// OnPaste transforms the pasted text before it's inserted, see OptionOnPaste.
+type OnPaste func(text string) string
+
+// pastedText returns the text between the markers of a paste, with the line breaks sent by the terminal normalized.
+func pastedText(b []byte) string {
+	b = bytes.TrimPrefix(b, pasteStart)
+	b = bytes.TrimSuffix(b, pasteEnd)
+	return strings.ReplaceAll(string(b), "\r\n", "\n")
+}
+
+// insertPaste inserts the pasted text verbatim, without running the key bindings, the completion
+// or the statement terminator for the characters in it.
+func (p *Prompt) insertPaste(b []byte) {
+	if p.search.active {
+		p.endHistorySearch()
+	}
+	text := pastedText(b)
+	if p.onPaste != nil {
+		text = p.onPaste(text)
+	}
+	p.buf.InsertText(text, false, true)
+}
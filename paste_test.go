@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedPaste(t *testing.T) {
+	scenarios := []struct {
+		name    string
+		paste   string
+		onPaste OnPaste
+		want    string
+	}{
+		{name: "line breaks and tabs are inserted", paste: "select *\r\nfrom t;\r\tdrop t;", want: "typed select *\nfrom t;\n\tdrop t;"},
+		{name: "on paste", paste: "select 1", onPaste: strings.ToUpper, want: "typed SELECT 1"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			p := newTestPrompt(EmacsKeyBind)
+			p.completion = NewCompletionManager(func(Document) []Suggest { return []Suggest{{Text: "select"}} }, 1)
+			p.statementTerminatorCb = func(Key, *Buffer) bool {
+				return true
+			}
+			p.onPaste = s.onPaste
+			p.buf.InsertText("typed ", false, true)
+
+			exec, err := p.feed([]byte("\x1b[200~" + s.paste + "\x1b[201~"))
+			require.NoError(t, err)
+			require.Nil(t, exec)
+			require.Equal(t, s.want, p.buf.Text())
+			require.False(t, p.completion.Completing())
+
+			// the paste is undone at once
+			require.True(t, p.buf.Undo())
+			require.Equal(t, "typed ", p.buf.Text())
+		})
+	}
+}
+
+func TestBracketedPasteMode(t *testing.T) {
+	p := newFileInputPrompt(t)
+	out := &recordingWriter{}
+	p.Renderer().out = out
+
+	go p.Accept()
+	_, err := p.InputContext(context.Background())
+	require.NoError(t, err)
+
+	enabled := strings.Index(out.String(), "\x1b[?2004h")
+	disabled := strings.LastIndex(out.String(), "\x1b[?2004l")
+	require.GreaterOrEqual(t, enabled, 0)
+	require.Greater(t, disabled, enabled)
+}
@@ -2,7 +2,14 @@ package prompt
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/confluentinc/go-prompt/internal/debug"
@@ -12,6 +19,10 @@ import (
 // Executor is called when user input something text.
 type Executor func(string)
 
+// ExecutorWithError is an Executor reporting whether the input failed, which is recorded in the history.
+// See OptionExecutorWithError.
+type ExecutorWithError func(string) error
+
 // ExitChecker is called after user input to check if prompt must stop and exit go-prompt Run loop.
 // User input means: selecting/typing an entry, then, if said entry content matches the ExitChecker function criteria:
 // - immediate exit (if breakline is false) without executor called
@@ -27,10 +38,14 @@ type StatementTerminatorCb func(lastKeyStroke Key, buffer *Buffer) bool
 
 type IPrompt interface {
 	Run()
+	RunContext(ctx context.Context) error
 	Input() string
+	InputContext(ctx context.Context) (string, error)
 	ClearScreen()
 	SetConsoleParser(ConsoleParser)
+	Close() error
 	Buffer() *Buffer
+	KillRing() *KillRing
 	Renderer() *Render
 	History() *History
 	Lexer() *Lexer
@@ -39,9 +54,29 @@ type IPrompt interface {
 	AddASCIICodeBindings(...ASCIICodeBind)
 	SetKeyBindMode(KeyBindMode)
 	SetCompletionOnDown(bool)
+	SetHistoryPrefixSearch(bool)
+	SetAutoSuggester(AutoSuggester)
+	SetOnPaste(OnPaste)
+	SetEscapeTimeout(time.Duration)
+	SetModifierKeys(bool)
+	SetMouse(bool)
+	SetConvertMeta(bool)
 	SetExitChecker(ExitChecker)
 	SetStatementTerminatorCb(StatementTerminatorCb)
+	SetSignalHandler(SignalHandler)
+	SetExecutorWithError(ExecutorWithError)
+	Signals() <-chan os.Signal
 	SetDiagnostics(diagnostics []lsp.Diagnostic)
+	Do(func(*Buffer))
+	SetText(string)
+	SetCursor(int)
+	Accept()
+	Cancel()
+	Invalidate()
+	ViMode() ViMode
+	Println(...any)
+	Printf(string, ...any)
+	Stdout() io.Writer
 }
 
 // Prompt is core struct of go-prompt.
@@ -52,6 +87,7 @@ type Prompt struct {
 	lastKey               Key
 	renderer              *Render
 	executor              Executor
+	executorWithError     ExecutorWithError
 	history               *History
 	diagnostics           []lsp.Diagnostic
 	lexer                 *Lexer
@@ -59,12 +95,37 @@ type Prompt struct {
 	keyBindings           []KeyBind
 	ASCIICodeBindings     []ASCIICodeBind
 	keyBindMode           KeyBindMode
+	vi                    viState
+	killRing              *KillRing
+	search                historySearch
 	completionOnDown      bool
+	historyPrefixSearch   bool
+	historyPrefix         string // text before the cursor when the user started to go through the history
+	autoSuggester         AutoSuggester
+	onPaste               OnPaste
+	escapeTimeout         time.Duration
+	modifierKeys          bool
+	mouse                 bool
+	convertMeta           bool
+	mouseState            mouseState
+	keyEvent              KeyEvent // the key being handled, with its modifiers when OptionModifierKeys is used
 	exitChecker           ExitChecker
 	statementTerminatorCb StatementTerminatorCb
+	actionsMu             sync.Mutex
+	actions               []action
+	actionCh              chan struct{} // receives a value when actions are queued
+	output                []string      // output written above the prompt by the event loop
+	outputActive          bool          // whether the event loop writes the output, otherwise it is written immediately
+	signalHandler         SignalHandler
+	signals               chan os.Signal
+	executing             atomic.Bool
 	skipTearDown          bool
 }
 
+// action runs on the event loop. Like feed, it returns a non-nil Exec to accept the input,
+// and a non-nil error to stop the prompt.
+type action func() (*Exec, error)
+
 // Exec is the struct contains user input context.
 type Exec struct {
 	input string
@@ -72,11 +133,21 @@ type Exec struct {
 
 // Run starts prompt.
 func (p *Prompt) Run() {
+	_ = p.RunContext(context.Background())
+}
+
+// RunContext starts prompt and blocks until it stops.
+// It returns nil when the ExitChecker stops the prompt, ErrEOF when the user sends EOF on an empty buffer,
+// an InterruptedError when the SignalHandler stops the prompt and ErrCanceled when ctx is done.
+// Signals are still handled while the executor is running, see OptionSignalHandler.
+func (p *Prompt) RunContext(ctx context.Context) error {
 	p.skipTearDown = false
 	defer debug.Teardown()
 	debug.Log("start prompt")
+	defer p.setOutputActive(false)
 	p.setUp()
 	defer p.tearDown()
+	p.setOutputActive(true)
 
 	if p.completion.showAtStart {
 		p.completion.Update(*p.buf.Document())
@@ -85,68 +156,110 @@ func (p *Prompt) Run() {
 	p.Render()
 
 	bufCh := make(chan []byte, 128)
-	stopReadBufCh := make(chan struct{})
-	go p.readBuffer(bufCh, stopReadBufCh)
+	stopReadBuffer := p.startReadBuffer(bufCh)
+	defer func() { stopReadBuffer() }()
 
-	exitCh := make(chan int)
+	interruptCh := make(chan os.Signal)
 	winSizeCh := make(chan *WinSize)
-	stopHandleSignalCh := make(chan struct{})
-	go p.handleSignals(exitCh, winSizeCh, stopHandleSignalCh)
+	defer startWorker(func(stop chan struct{}) { p.handleSignals(interruptCh, winSizeCh, stop) }, nil)()
+
+	completionCtx, cancelCompletion := context.WithCancel(ctx)
+	defer cancelCompletion()
+	defer p.completion.CancelUpdate()
+	completionCh := make(chan struct{})
 
 	for {
+		var e *Exec
+		var err error
 		select {
+		case <-ctx.Done():
+			p.renderer.BreakLine(p.buf, p.lexer)
+			return canceledError(ctx)
 		case b := <-bufCh:
-			if shouldExit, e := p.feed(b); shouldExit {
-				p.renderer.BreakLine(p.buf, p.lexer)
-				stopReadBufCh <- struct{}{}
-				stopHandleSignalCh <- struct{}{}
-				return
-			} else if e != nil {
-				// Stop goroutine to run readBuffer function
-				stopReadBufCh <- struct{}{}
-				stopHandleSignalCh <- struct{}{}
+			if p.mouse && p.handleCursorPosition(b) {
+				// the answer to requestCursorPosition changes nothing on the screen
+				continue
+			}
+			e, err = p.feed(b)
+		case <-p.actionCh:
+			document := *p.buf.Document()
+			if e, err = p.runActions(); e == nil && err == nil && document == *p.buf.Document() {
+				// nothing to complete again
+				p.Render()
+				continue
+			}
+		case w := <-winSizeCh:
+			p.renderer.UpdateWinSize(w)
+			p.Render()
+			continue
+		case sig := <-interruptCh:
+			p.renderer.BreakLine(p.buf, p.lexer)
+			return &InterruptedError{Signal: sig}
+		case <-completionCh:
+			p.Render()
+			continue
+		}
 
-				// Unset raw mode
-				// Reset to Blocking mode because returned EAGAIN when still set non-blocking mode.
-				debug.AssertNoError(p.in.TearDown())
-				p.executor(e.input)
+		if err != nil {
+			p.renderer.BreakLine(p.buf, p.lexer)
+			if errors.Is(err, ErrExited) {
+				return nil
+			}
+			return err
+		} else if e != nil {
+			// Stop goroutine to run readBuffer function
+			stopReadBuffer()
 
-				p.completion.Update(*p.buf.Document())
+			// Unset raw mode
+			// Reset to Blocking mode because returned EAGAIN when still set non-blocking mode.
+			debug.AssertNoError(p.in.TearDown())
+			p.setInputModes(false)
+			debug.AssertNoError(p.renderer.out.Flush())
+			start := time.Now()
+			execErr := p.execute(e.input)
+			p.addHistory(HistoryEntry{Text: e.input, Time: start, Duration: time.Since(start), Failed: execErr != nil})
 
-				p.Render()
+			p.updateCompletion(completionCtx, completionCh)
 
-				if p.exitChecker != nil && p.exitChecker(e.input, true) {
-					p.skipTearDown = true
-					return
-				}
-				// Set raw mode
-				debug.AssertNoError(p.in.Setup())
-				go p.readBuffer(bufCh, stopReadBufCh)
-				go p.handleSignals(exitCh, winSizeCh, stopHandleSignalCh)
-			} else {
+			p.Render()
 
-				p.completion.Update(*p.buf.Document())
-				p.Render()
+			if p.exitChecker != nil && p.exitChecker(e.input, true) {
+				p.skipTearDown = true
+				return nil
+			}
+			// Set raw mode
+			debug.AssertNoError(p.in.Setup())
+			p.setInputModes(true)
+			// the prompt was rendered before the raw mode, so its position wasn't asked yet
+			p.requestCursorPosition()
+			debug.AssertNoError(p.renderer.out.Flush())
+			stopReadBuffer = p.startReadBuffer(bufCh)
+		} else {
+			// we don't want to trigger completions again while navigating existing completions
+			if !p.completion.Completing() {
+				p.updateCompletion(completionCtx, completionCh)
 			}
-		case w := <-winSizeCh:
-			p.renderer.UpdateWinSize(w)
 			p.Render()
-		case code := <-exitCh:
-			p.renderer.BreakLine(p.buf, p.lexer)
-			p.tearDown()
-			os.Exit(code)
-		default:
-			time.Sleep(10 * time.Millisecond)
 		}
 	}
 }
 
 // Input just returns user input text.
 func (p *Prompt) Input() string {
+	text, _ := p.InputContext(context.Background())
+	return text
+}
+
+// InputContext returns user input text and blocks until the user accepts it.
+// It returns ErrEOF when the user sends EOF on an empty buffer, ErrExited when the ExitChecker stops the prompt,
+// an InterruptedError when the SignalHandler stops the prompt and ErrCanceled when ctx is done.
+func (p *Prompt) InputContext(ctx context.Context) (string, error) {
 	defer debug.Teardown()
 	debug.Log("start prompt")
+	defer p.setOutputActive(false)
 	p.setUp()
 	defer p.tearDown()
+	p.setOutputActive(true)
 
 	if p.completion.showAtStart {
 		p.completion.Update(*p.buf.Document())
@@ -154,49 +267,101 @@ func (p *Prompt) Input() string {
 
 	p.Render()
 	bufCh := make(chan []byte, 128)
-	stopReadBufCh := make(chan struct{})
-	go p.readBuffer(bufCh, stopReadBufCh)
+	defer p.startReadBuffer(bufCh)()
 
-	completionCh := make(chan bool)
-	exitCh := make(chan int)
+	interruptCh := make(chan os.Signal)
 	winSizeCh := make(chan *WinSize)
-	stopHandleSignalCh := make(chan struct{})
-	go p.handleSignals(exitCh, winSizeCh, stopHandleSignalCh)
+	defer startWorker(func(stop chan struct{}) { p.handleSignals(interruptCh, winSizeCh, stop) }, nil)()
+
+	completionCtx, cancelCompletion := context.WithCancel(ctx)
+	defer cancelCompletion()
+	defer p.completion.CancelUpdate()
+	completionCh := make(chan struct{})
 
 	for {
+		var e *Exec
+		var err error
 		select {
+		case <-ctx.Done():
+			p.renderer.BreakLine(p.buf, p.lexer)
+			return "", canceledError(ctx)
 		case b := <-bufCh:
-			if shouldExit, e := p.feed(b); shouldExit {
-				p.renderer.BreakLine(p.buf, p.lexer)
-				stopReadBufCh <- struct{}{}
-				stopHandleSignalCh <- struct{}{}
-				return ""
-			} else if e != nil {
-				// Stop goroutine to run readBuffer function
-				stopReadBufCh <- struct{}{}
-				stopHandleSignalCh <- struct{}{}
-				return e.input
-			} else {
-				document := *p.buf.Document()
-				// we don't want to trigger completions again while navigating existing completions
-				if !p.completion.Completing() {
-					go func() {
-						p.completion.Update(document)
-						completionCh <- true
-					}()
-				}
+			if p.mouse && p.handleCursorPosition(b) {
+				// the answer to requestCursorPosition changes nothing on the screen
+				continue
+			}
+			e, err = p.feed(b)
+		case <-p.actionCh:
+			document := *p.buf.Document()
+			if e, err = p.runActions(); e == nil && err == nil && document == *p.buf.Document() {
+				// nothing to complete again
 				p.Render()
+				continue
 			}
 		case w := <-winSizeCh:
 			p.renderer.UpdateWinSize(w)
 			p.Render()
-		case code := <-exitCh:
+			continue
+		case sig := <-interruptCh:
 			p.renderer.BreakLine(p.buf, p.lexer)
-			p.tearDown()
-			os.Exit(code)
+			return "", &InterruptedError{Signal: sig}
 		case <-completionCh:
 			p.Render()
+			continue
+		}
+
+		if err != nil {
+			p.renderer.BreakLine(p.buf, p.lexer)
+			return "", err
+		} else if e != nil {
+			p.addHistory(HistoryEntry{Text: e.input})
+			return e.input, nil
+		} else {
+			// we don't want to trigger completions again while navigating existing completions
+			if !p.completion.Completing() {
+				p.updateCompletion(completionCtx, completionCh)
+			}
+			p.Render()
+		}
+	}
+}
+
+// updateCompletion updates the suggestions in the background.
+// completionCh receives a value whenever the completion menu has to be rendered again.
+func (p *Prompt) updateCompletion(ctx context.Context, completionCh chan struct{}) {
+	p.completion.UpdateAsync(ctx, *p.buf.Document(), func() {
+		select {
+		case completionCh <- struct{}{}:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// execute runs the executor and returns the error of the ExecutorWithError.
+// Signals forwarded by the SignalHandler are passed to it through Signals meanwhile.
+func (p *Prompt) execute(input string) error {
+	p.setOutputActive(false)
+	p.executing.Store(true)
+	defer func() {
+		p.setOutputActive(true)
+		p.executing.Store(false)
+		// drop the signal the executor has not received, so that the next executor doesn't receive it
+		select {
+		case <-p.signals:
+		default:
 		}
+	}()
+	if p.executorWithError != nil {
+		return p.executorWithError(input)
+	}
+	p.executor(input)
+	return nil
+}
+
+// addHistory adds the accepted input to the history unless it's empty.
+func (p *Prompt) addHistory(entry HistoryEntry) {
+	if entry.Text != "" {
+		p.history.AddEntry(entry)
 	}
 }
 
@@ -205,14 +370,35 @@ func (p *Prompt) ClearScreen() {
 	p.renderer.ClearScreen()
 }
 
+// SetConsoleParser sets the ConsoleParser of the prompt, and closes the previous one when it is an io.Closer.
 func (p *Prompt) SetConsoleParser(parser ConsoleParser) {
+	if c, ok := p.in.(io.Closer); ok {
+		debug.AssertNoError(c.Close())
+	}
 	p.in = parser
 }
 
+// Close releases the ConsoleParser of the prompt when it is an io.Closer, like the file descriptors of
+// the PosixParser. The prompt can't be run afterwards.
+func (p *Prompt) Close() error {
+	if c, ok := p.in.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 func (p *Prompt) Buffer() *Buffer {
 	return p.buf
 }
 
+// KillRing returns the kill ring shared by the buffers of the prompt, e.g. to seed it or to read the killed texts.
+func (p *Prompt) KillRing() *KillRing {
+	if p.killRing == nil {
+		p.killRing = NewKillRing(DefaultKillRingSize)
+	}
+	return p.killRing
+}
+
 func (p *Prompt) Renderer() *Render {
 	return p.renderer
 }
@@ -245,6 +431,41 @@ func (p *Prompt) SetCompletionOnDown(completionOnDown bool) {
 	p.completionOnDown = completionOnDown
 }
 
+// SetHistoryPrefixSearch sets whether Up and Down only go through the history entries starting with the text before the cursor.
+func (p *Prompt) SetHistoryPrefixSearch(historyPrefixSearch bool) {
+	p.historyPrefixSearch = historyPrefixSearch
+}
+
+// SetAutoSuggester sets the source of the suggestions shown after the cursor, nil disables them.
+func (p *Prompt) SetAutoSuggester(autoSuggester AutoSuggester) {
+	p.autoSuggester = autoSuggester
+}
+
+// SetOnPaste sets the function transforming the pasted text before it's inserted.
+func (p *Prompt) SetOnPaste(onPaste OnPaste) {
+	p.onPaste = onPaste
+}
+
+// SetEscapeTimeout sets how long the prompt waits for the rest of an incomplete sequence, e.g. after ESC.
+func (p *Prompt) SetEscapeTimeout(timeout time.Duration) {
+	p.escapeTimeout = timeout
+}
+
+// SetModifierKeys sets whether the terminal is asked to report the modifiers of the keys, see OptionModifierKeys.
+func (p *Prompt) SetModifierKeys(modifierKeys bool) {
+	p.modifierKeys = modifierKeys
+}
+
+// SetConvertMeta sets whether the bytes with the eighth bit set are Meta keys, see OptionConvertMeta.
+func (p *Prompt) SetConvertMeta(convertMeta bool) {
+	p.convertMeta = convertMeta
+}
+
+// SetMouse sets whether the terminal reports the mouse, see OptionMouse.
+func (p *Prompt) SetMouse(mouse bool) {
+	p.mouse = mouse
+}
+
 func (p *Prompt) SetExitChecker(exitChecker ExitChecker) {
 	p.exitChecker = exitChecker
 }
@@ -253,10 +474,211 @@ func (p *Prompt) SetStatementTerminatorCb(statementTerminatorCb StatementTermina
 	p.statementTerminatorCb = statementTerminatorCb
 }
 
+func (p *Prompt) SetSignalHandler(signalHandler SignalHandler) {
+	p.signalHandler = signalHandler
+}
+
+// SetExecutorWithError sets the executor used instead of the Executor passed to New.
+func (p *Prompt) SetExecutorWithError(executor ExecutorWithError) {
+	p.executorWithError = executor
+}
+
+// Signals returns the channel receiving the signals which are forwarded to the running executor.
+// See SignalForward.
+func (p *Prompt) Signals() <-chan os.Signal {
+	return p.signals
+}
+
+// SetDiagnostics sets the diagnostics shown until the text changes.
+// It is safe to call from any goroutine, see Do.
 func (p *Prompt) SetDiagnostics(diagnostics []lsp.Diagnostic) {
-	p.diagnostics = diagnostics
-	p.prevText = p.buf.Text()
-	p.Render()
+	p.enqueueAction(func() (*Exec, error) {
+		p.diagnostics = diagnostics
+		p.prevText = p.buf.Text()
+		return nil, nil
+	})
+}
+
+// Do runs fn with the buffer on the event loop of the prompt, and renders the prompt afterwards.
+// It is safe to call from any goroutine and doesn't wait until fn has run.
+// If the prompt is not running, fn runs when it starts.
+func (p *Prompt) Do(fn func(*Buffer)) {
+	p.enqueueAction(func() (*Exec, error) {
+		fn(p.buf)
+		return nil, nil
+	})
+}
+
+// SetText replaces the text in the buffer and moves the cursor to its end.
+// It is safe to call from any goroutine, see Do.
+func (p *Prompt) SetText(text string) {
+	p.Do(func(buf *Buffer) {
+		buf.setDocument(&Document{Text: text, cursorPosition: len([]rune(text))})
+		buf.preferredColumn = -1
+	})
+}
+
+// SetCursor moves the cursor to the given index in the runes of the text.
+// It is safe to call from any goroutine, see Do.
+func (p *Prompt) SetCursor(index int) {
+	p.Do(func(buf *Buffer) {
+		buf.setCursorPosition(min(index, len([]rune(buf.Text()))))
+		buf.preferredColumn = -1
+	})
+}
+
+// Accept accepts the text in the buffer as if the user has terminated the statement.
+// It is safe to call from any goroutine, see Do.
+func (p *Prompt) Accept() {
+	p.enqueueAction(func() (*Exec, error) {
+		return p.accept(), nil
+	})
+}
+
+// Cancel stops the prompt, Run and Input return ErrCanceled.
+// It is safe to call from any goroutine, see Do.
+func (p *Prompt) Cancel() {
+	p.enqueueAction(func() (*Exec, error) {
+		return nil, ErrCanceled
+	})
+}
+
+// Invalidate renders the prompt again.
+// It is safe to call from any goroutine, see Do.
+func (p *Prompt) Invalidate() {
+	p.enqueueAction(func() (*Exec, error) {
+		return nil, nil
+	})
+}
+
+// enqueueAction queues fn to run on the event loop and wakes it up.
+func (p *Prompt) enqueueAction(fn action) {
+	p.actionsMu.Lock()
+	p.actions = append(p.actions, fn)
+	p.actionsMu.Unlock()
+
+	select {
+	case p.actionCh <- struct{}{}:
+	default:
+		// the event loop has already been woken up
+	}
+}
+
+// runActions writes the pending output above the prompt,
+// and runs the queued actions until one of them accepts the input or stops the prompt.
+func (p *Prompt) runActions() (exec *Exec, err error) {
+	p.actionsMu.Lock()
+	output := strings.Join(p.output, "")
+	p.output = nil
+	p.actionsMu.Unlock()
+	if output != "" {
+		p.renderer.WriteAbove(output)
+	}
+
+	for {
+		p.actionsMu.Lock()
+		if len(p.actions) == 0 {
+			p.actionsMu.Unlock()
+			return nil, nil
+		}
+		fn := p.actions[0]
+		p.actions = p.actions[1:]
+		remaining := len(p.actions) > 0
+		p.actionsMu.Unlock()
+
+		p.prevText = p.buf.Text()
+		p.beginCommand()
+		buf := p.buf
+		exec, err = fn()
+		buf.endCommand(editOther)
+		if exec != nil || err != nil {
+			if remaining {
+				// run the remaining actions on the next iteration of the event loop
+				select {
+				case p.actionCh <- struct{}{}:
+				default:
+				}
+			}
+			return exec, err
+		}
+	}
+}
+
+// Println writes the operands above the prompt like fmt.Println.
+// It is safe to call from any goroutine, the prompt is rendered again below the output.
+func (p *Prompt) Println(a ...any) {
+	p.print(fmt.Sprintln(a...))
+}
+
+// Printf writes the formatted text above the prompt like fmt.Printf, followed by a newline if it doesn't end with one.
+// It is safe to call from any goroutine, the prompt is rendered again below the output.
+func (p *Prompt) Printf(format string, a ...any) {
+	p.print(fmt.Sprintf(format, a...))
+}
+
+// Stdout returns a writer which writes complete lines above the prompt.
+// An incomplete line is written once it's completed by a following write.
+// It is safe to use from any goroutine, the prompt is rendered again below the output.
+func (p *Prompt) Stdout() io.Writer {
+	return &promptWriter{p: p}
+}
+
+// print writes s above the prompt when the prompt is running, otherwise it writes s immediately.
+func (p *Prompt) print(s string) {
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+
+	p.actionsMu.Lock()
+	defer p.actionsMu.Unlock()
+
+	if !p.outputActive {
+		p.writeOutput(s)
+		return
+	}
+	p.output = append(p.output, s)
+	select {
+	case p.actionCh <- struct{}{}:
+	default:
+	}
+}
+
+// setOutputActive sets whether the event loop writes the output above the prompt.
+// The pending output is written immediately when the event loop stops writing it.
+func (p *Prompt) setOutputActive(active bool) {
+	p.actionsMu.Lock()
+	defer p.actionsMu.Unlock()
+
+	p.outputActive = active
+	if !active && len(p.output) > 0 {
+		p.writeOutput(strings.Join(p.output, ""))
+		p.output = nil
+	}
+}
+
+// writeOutput writes s while the prompt is not rendered. p.actionsMu must be held.
+func (p *Prompt) writeOutput(s string) {
+	p.renderer.out.WriteRawStr(s)
+	debug.AssertNoError(p.renderer.out.Flush())
+}
+
+// promptWriter is an io.Writer writing complete lines above the prompt.
+type promptWriter struct {
+	p   *Prompt
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *promptWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, b...)
+	if i := bytes.LastIndexByte(w.buf, '\n'); i >= 0 {
+		w.p.print(string(w.buf[:i+1]))
+		w.buf = append([]byte{}, w.buf[i+1:]...)
+	}
+	return len(b), nil
 }
 
 func (p *Prompt) ClearDiagnosticsOnTextChange() {
@@ -268,11 +690,31 @@ func (p *Prompt) ClearDiagnosticsOnTextChange() {
 
 func (p *Prompt) Render() {
 	p.ClearDiagnosticsOnTextChange()
+	p.renderer.autoSuggestion = p.autoSuggestion()
 	p.renderer.Render(p.buf, p.lastKey, p.completion, p.lexer, p.diagnostics)
+	if p.mouse {
+		p.requestCursorPosition()
+		debug.AssertNoError(p.renderer.out.Flush())
+	}
 }
 
-func (p *Prompt) feed(b []byte) (shouldExit bool, exec *Exec) {
+// feed processes the input bytes. A non-nil error means the prompt must stop:
+// ErrEOF when the user sends EOF on an empty buffer and ErrExited when the ExitChecker matched.
+func (p *Prompt) feed(b []byte) (exec *Exec, err error) {
 	key := GetKey(b)
+	p.keyEvent = KeyEvent{Key: key}
+	if p.modifierKeys {
+		// the rest works with the key and the bytes sent without the keyboard protocol
+		p.keyEvent, b = decodeKeyEvent(b)
+		key = p.keyEvent.Key
+	}
+	if p.keyBindMode == ViKeyBind && isMetaKey(b) && !p.hasKeyBinding(p.keyEvent) {
+		// there are no Meta keys in the vi mode, it's Escape followed quickly by another key
+		if exec, err = p.feed(b[:1]); exec != nil || err != nil {
+			return exec, err
+		}
+		return p.feed(b[1:])
+	}
 	p.prevText = p.buf.Text()
 	// We store the last key stroke pressed to p.lastKey in the render to understand what was the last action taken.
 	// For example: if the last action was going to the next erase, we want to erase the statement
@@ -280,25 +722,58 @@ func (p *Prompt) feed(b []byte) (shouldExit bool, exec *Exec) {
 	// and not erase the last statement. This could also be used for other functionalities in the future.
 	p.lastKey = key
 	p.buf.lastKeyStroke = key
+	p.beginCommand()
+	buf, edit := p.buf, editOther
+	defer func() {
+		// the buffer is replaced when going through the history, which is not undone
+		if p.buf == buf {
+			buf.endCommand(edit)
+		}
+	}()
+	if key == BracketedPaste {
+		p.insertPaste(b)
+		return
+	}
+	if key == Vt100MouseEvent {
+		p.handleMouse(b)
+		return
+	}
+	if p.search.active && p.handleHistorySearch(key, b) {
+		return
+	}
+	if p.keyEvent.Mods != 0 && p.hasKeyBinding(p.keyEvent) {
+		// a binding of the modifiers replaces what the key does without them
+		if p.handleKeyBinding(key, p.keyEvent.Mods) {
+			err = ErrExited
+		}
+		return
+	}
 	// completion
 	completing := p.completion.Completing()
-	p.handleCompletionKeyBinding(key, completing)
+	completed := p.handleCompletionKeyBinding(key, completing)
+
+	if !completed && p.handleAutoSuggestionKeyBinding(key) {
+		return
+	}
+
+	if p.keyBindMode == ViKeyBind && p.handleViKeyBinding(key, b) {
+		return
+	}
 
 	switch key {
 	case Enter, ControlJ, ControlM, AltEnter:
-		if p.statementTerminatorCb == nil || !p.statementTerminatorCb(p.buf.lastKeyStroke, p.buf) {
+		if p.keyEvent.Mods != 0 {
+			// e.g. Shift+Enter
+			p.buf.NewLine(false)
+		} else if p.statementTerminatorCb == nil || !p.statementTerminatorCb(p.buf.lastKeyStroke, p.buf) {
 			p.buf.NewLine(false)
 		} else {
-			p.renderer.BreakLine(p.buf, p.lexer)
-			exec = &Exec{input: p.buf.Text()}
-			p.buf = NewBuffer()
-			if exec.input != "" {
-				p.history.Add(exec.input)
-			}
+			exec = p.accept()
 		}
 	case ControlC:
 		p.renderer.BreakLine(p.buf, p.lexer)
 		p.buf = NewBuffer()
+		p.resetVi()
 		p.history.Clear()
 	case Up, ControlP:
 		if !completing { // Don't use p.completion.Completing() because it takes double operation when switch to selected=-1.
@@ -306,7 +781,7 @@ func (p *Prompt) feed(b []byte) (shouldExit bool, exec *Exec) {
 				// this is a multiline buffer
 				// move the cursor up by one line
 				p.buf.CursorUp(1)
-			} else if newBuf, changed := p.history.Older(p.buf); changed {
+			} else if newBuf, changed := p.olderHistory(); changed {
 				p.prevText = p.buf.Text()
 				p.buf = newBuf
 			}
@@ -317,18 +792,26 @@ func (p *Prompt) feed(b []byte) (shouldExit bool, exec *Exec) {
 		if !completing { // Don't use p.completion.Completing() because it takes double operation when switch to selected=-1.
 			if p.buf.HasNextLine() {
 				p.buf.CursorDown(1)
-			} else if newBuf, changed := p.history.Newer(p.buf); changed {
+			} else if newBuf, changed := p.newerHistory(); changed {
 
 				p.prevText = p.buf.Text()
 				p.buf = newBuf
 			}
 			return
 		}
+	case ControlR, ControlS:
+		if p.keyBindMode == EmacsKeyBind {
+			p.startHistorySearch(key == ControlR)
+			return
+		}
 	case ControlD:
 		if p.buf.Text() == "" {
-			shouldExit = true
+			err = ErrEOF
 			return
 		}
+	case Ignore:
+		// an unknown escape sequence, which may be bound by AddASCIICodeBindings
+		p.handleASCIICodeBinding(b)
 	case NotDefined:
 		if p.handleASCIICodeBinding(b) {
 			return
@@ -337,22 +820,67 @@ func (p *Prompt) feed(b []byte) (shouldExit bool, exec *Exec) {
 		// special characters that mess with the rendering (e.g. the escape char)
 		cleanedInput := RemoveASCIISequences(b)
 		p.buf.InsertText(string(cleanedInput), false, true)
+		if !completed {
+			edit = editTyping
+		}
 
 		// By pressing anykey which isn't mapped we again show completions if they were hidden (by pressing escape)
 		p.renderer.hideCompletion = false
 	}
 
-	shouldExit = p.handleKeyBinding(key)
+	if !completed && (key == Backspace || key == Delete || key == ControlH) {
+		edit = editDeleting
+	}
+	if p.handleKeyBinding(key, 0) {
+		err = ErrExited
+	}
 	return
 }
 
+// beginCommand prepares the buffer for the command about to run.
+func (p *Prompt) beginCommand() {
+	p.buf.killRing = p.KillRing()
+	p.buf.beginCommand()
+}
+
+// accept breaks the line and returns the text in the buffer.
+// The event loop adds it to the history, after running the executor so that its duration is recorded.
+func (p *Prompt) accept() *Exec {
+	p.renderer.BreakLine(p.buf, p.lexer)
+	exec := &Exec{input: p.buf.Text()}
+	p.buf = NewBuffer()
+	p.resetVi()
+	return exec
+}
+
+// olderHistory returns the previous entry of the history. When OptionHistoryPrefixSearch is used, it's the previous entry
+// starting with the text which was before the cursor when the user started to go through the history.
+func (p *Prompt) olderHistory() (*Buffer, bool) {
+	if !p.historyPrefixSearch {
+		return p.history.Older(p.buf)
+	}
+	if !p.history.HasNewer() {
+		p.historyPrefix = p.buf.Document().TextBeforeCursor()
+	}
+	return p.history.OlderWithPrefix(p.buf, p.historyPrefix)
+}
+
+// newerHistory is the counterpart of olderHistory.
+func (p *Prompt) newerHistory() (*Buffer, bool) {
+	if !p.historyPrefixSearch {
+		return p.history.Newer(p.buf)
+	}
+	return p.history.NewerWithPrefix(p.buf, p.historyPrefix)
+}
+
 // Wheter or not we'll enter completions when the user presses down. We only navigate into completions if there's no new line below(multiline buffer) and history is not active
 // (we're not browsing history with arros).
 func (p *Prompt) completeOnDown() bool {
 	return p.completionOnDown && !p.history.HasNewer() && !p.buf.HasNextLine()
 }
 
-func (p *Prompt) handleCompletionKeyBinding(key Key, completing bool) {
+// handleCompletionKeyBinding handles the completion keys and reports whether the selected suggestion was inserted.
+func (p *Prompt) handleCompletionKeyBinding(key Key, completing bool) (inserted bool) {
 	switch key {
 	case Down:
 		if completing || p.completeOnDown() {
@@ -376,21 +904,34 @@ func (p *Prompt) handleCompletionKeyBinding(key Key, completing bool) {
 				p.buf.DeleteBeforeCursor(len([]rune(w)))
 			}
 			p.buf.InsertText(s.Text, false, true)
+			inserted = true
 		}
 		p.completion.Reset()
 	}
+	return inserted
 }
 
-func (p *Prompt) handleKeyBinding(key Key) bool {
+// hasKeyBinding returns whether a custom key binding matches the key and the modifiers of ev.
+func (p *Prompt) hasKeyBinding(ev KeyEvent) bool {
+	for _, kb := range p.keyBindings {
+		if kb.Key == ev.Key && kb.Mods == ev.Mods {
+			return true
+		}
+	}
+	return false
+}
+
+// handleKeyBinding runs the key bindings of key with the modifiers mods. The default key bindings have no modifiers.
+func (p *Prompt) handleKeyBinding(key Key, mods Modifiers) bool {
 	shouldExit := false
 	for i := range commonKeyBindings {
 		kb := commonKeyBindings[i]
-		if kb.Key == key {
+		if kb.Key == key && mods == 0 {
 			kb.Fn(p.buf)
 		}
 	}
 
-	if p.keyBindMode == EmacsKeyBind {
+	if p.keyBindMode == EmacsKeyBind && mods == 0 {
 		for i := range emacsKeyBindings {
 			kb := emacsKeyBindings[i]
 			if kb.Key == key {
@@ -402,7 +943,7 @@ func (p *Prompt) handleKeyBinding(key Key) bool {
 	// Custom key bindings
 	for i := range p.keyBindings {
 		kb := p.keyBindings[i]
-		if kb.Key == key {
+		if kb.Key == key && kb.Mods == mods {
 			kb.Fn(p.buf)
 		}
 	}
@@ -423,19 +964,89 @@ func (p *Prompt) handleASCIICodeBinding(b []byte) bool {
 	return checked
 }
 
+// startWorker runs fn in a goroutine. The returned function closes the stop channel
+// passed to fn, calls wakeUp if it is not nil and waits until fn has returned.
+func startWorker(fn func(stop chan struct{}), wakeUp func()) (stop func()) {
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		fn(stopCh)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopCh)
+			if wakeUp != nil {
+				wakeUp()
+			}
+			<-doneCh
+		})
+	}
+}
+
+// startReadBuffer starts the goroutine reading the input.
+func (p *Prompt) startReadBuffer(bufCh chan []byte) (stop func()) {
+	var wakeUp func()
+	if in, ok := p.in.(CancelableConsoleParser); ok {
+		wakeUp = func() { debug.AssertNoError(in.Cancel()) }
+	}
+	return startWorker(func(stop chan struct{}) { p.readBuffer(bufCh, stop) }, wakeUp)
+}
+
 func (p *Prompt) readBuffer(bufCh chan []byte, stopCh chan struct{}) {
 	debug.Log("start reading buffer")
+	in, blocking := p.in.(CancelableConsoleParser)
+	decoder := newKeyDecoder(p.ASCIICodeBindings)
+	decoder.convertMeta = p.convertMeta
+	// an incomplete sequence is decoded as it is when nothing follows it before the deadline, e.g. ESC is the Escape key
+	var deadline time.Time
+	var wakeUp *time.Timer
+	defer func() {
+		if wakeUp != nil {
+			wakeUp.Stop()
+		}
+	}()
+
 	for {
 		select {
 		case <-stopCh:
 			debug.Log("stop reading buffer")
 			return
 		default:
-			if b, err := p.in.Read(); err == nil && !(len(b) == 1 && b[0] == 0) {
-				bufCh <- b
+		}
+
+		var chunks [][]byte
+		b, err := p.in.Read()
+		if err == nil && len(b) > 0 && !(len(b) == 1 && b[0] == 0) {
+			chunks = decoder.decode(b)
+			deadline = time.Now().Add(p.escapeTimeout)
+			if decoder.pending() && blocking && p.escapeTimeout > 0 {
+				if wakeUp != nil {
+					wakeUp.Stop()
+				}
+				wakeUp = time.AfterFunc(p.escapeTimeout, func() { debug.AssertNoError(in.Cancel()) })
+			}
+		}
+		if decoder.pending() && !time.Now().Before(deadline) {
+			chunks = append(chunks, decoder.flush()...)
+		}
+
+		for _, chunk := range chunks {
+			select {
+			case bufCh <- chunk:
+			case <-stopCh:
+				debug.Log("stop reading buffer")
+				return
 			}
 		}
-		time.Sleep(10 * time.Millisecond)
+
+		// Parsers which don't block until input is available have to be polled,
+		// and a failing read must not spin either.
+		if !blocking || (err != nil && !errors.Is(err, ErrReadCanceled)) {
+			time.Sleep(10 * time.Millisecond)
+		}
 	}
 }
 
@@ -443,11 +1054,14 @@ func (p *Prompt) setUp() {
 	debug.AssertNoError(p.in.Setup())
 	p.renderer.Setup()
 	p.renderer.UpdateWinSize(p.in.GetWinSize())
+	p.setInputModes(true)
+	debug.AssertNoError(p.renderer.out.Flush())
 }
 
 func (p *Prompt) tearDown() {
 	if !p.skipTearDown {
 		debug.AssertNoError(p.in.TearDown())
 	}
+	p.setInputModes(false)
 	p.renderer.TearDown()
 }
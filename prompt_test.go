@@ -1,12 +1,29 @@
 package prompt
 
 import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/sourcegraph/go-lsp"
 	"github.com/stretchr/testify/require"
 )
 
+// newTestPrompt returns a prompt with an empty buffer and history and without completions, to feed keys to.
+func newTestPrompt(mode KeyBindMode) *Prompt {
+	return &Prompt{
+		buf:         NewBuffer(),
+		history:     NewHistory(),
+		renderer:    &Render{},
+		completion:  NewCompletionManager(nil, 0),
+		keyBindMode: mode,
+	}
+}
+
 func TestClearDiagnosticsOnTextChange(t *testing.T) {
 	// Create a Prompt instance with buf.Text() != prevText
 	b := NewBuffer()
@@ -147,3 +164,357 @@ func TestFeedEscape(t *testing.T) {
 	require.Equal(t, p.completion.verticalScroll, 0)
 	require.Equal(t, len(p.completion.tmp), 0)
 }
+
+func TestFeedControlDOnEmptyBuffer(t *testing.T) {
+	p := newTestPrompt(EmacsKeyBind)
+
+	_, err := p.feed([]byte{0x4})
+	require.ErrorIs(t, err, ErrEOF)
+
+	// Ctrl+D deletes a character when the buffer is not empty
+	p.buf.InsertText("a", false, false)
+	_, err = p.feed([]byte{0x4})
+	require.NoError(t, err)
+	require.Equal(t, "", p.buf.Text())
+}
+
+func TestFeedExitChecker(t *testing.T) {
+	p := newTestPrompt(EmacsKeyBind)
+	p.exitChecker = func(in string, breakline bool) bool { return in == "q" }
+
+	_, err := p.feed([]byte("q"))
+	require.ErrorIs(t, err, ErrExited)
+}
+
+func TestInputContextCanceled(t *testing.T) {
+	p := newFileInputPrompt(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	text, err := p.InputContext(ctx)
+	require.Equal(t, "", text)
+	require.ErrorIs(t, err, ErrCanceled)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestInterruptError(t *testing.T) {
+	var err error = &InterruptedError{Signal: os.Interrupt}
+	require.ErrorIs(t, err, ErrInterrupted)
+	require.NotErrorIs(t, err, ErrCanceled)
+}
+
+func newFileInputPrompt(t *testing.T) IPrompt {
+	file, err := os.CreateTemp(t.TempDir(), "input")
+	require.NoError(t, err)
+	t.Cleanup(func() { file.Close() })
+	t.Setenv(EnvVarInputFile, file.Name())
+
+	p, err := New(nil, nil, OptionWriter(NoopWriter{}))
+	require.NoError(t, err)
+	return p
+}
+
+func TestPromptControlFromOtherGoroutines(t *testing.T) {
+	p := newFileInputPrompt(t)
+
+	// called before the prompt is running
+	p.SetDiagnostics([]lsp.Diagnostic{{Message: "Error 1"}})
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				p.SetDiagnostics([]lsp.Diagnostic{{Message: "Error 2"}})
+				p.Invalidate()
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	go func() {
+		p.SetText("select")
+		p.SetCursor(3)
+		p.Do(func(buf *Buffer) {
+			buf.InsertText("X", false, true)
+		})
+		p.Accept()
+	}()
+
+	text, err := p.InputContext(context.Background())
+	close(stop)
+	<-done
+	require.NoError(t, err)
+	require.Equal(t, "selXect", text)
+	require.Equal(t, "", p.Buffer().Text())
+}
+
+func TestPromptCancel(t *testing.T) {
+	p := newFileInputPrompt(t)
+	p.SetText("foo")
+	p.Cancel()
+
+	text, err := p.InputContext(context.Background())
+	require.ErrorIs(t, err, ErrCanceled)
+	require.Equal(t, "", text)
+	require.Equal(t, "foo", p.Buffer().Text())
+}
+
+// recordingWriter records everything flushed to it.
+type recordingWriter struct {
+	VT100Writer
+	mu  sync.Mutex
+	out strings.Builder
+}
+
+func (w *recordingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.out.Write(w.buffer)
+	w.buffer = []byte{}
+	return nil
+}
+
+func (w *recordingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out.String()
+}
+
+func TestPromptPrint(t *testing.T) {
+	p := newFileInputPrompt(t)
+	out := &recordingWriter{}
+	p.Renderer().out = out
+
+	// written immediately before the prompt is running
+	p.Println("before", 1)
+	require.Equal(t, "before 1\n", out.String())
+
+	stdout := p.Stdout()
+	go func() {
+		p.Printf("status: %s", "ok")
+		_, _ = io.WriteString(stdout, "row 1\nrow ")
+		_, _ = io.WriteString(stdout, "2\n")
+		p.Accept()
+	}()
+
+	_, err := p.InputContext(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "status: ok\nrow 1\nrow 2\n")
+}
+
+func TestFeedUndo(t *testing.T) {
+	const undo = "\x1f" // Ctrl+_
+	scenarios := []struct {
+		name string
+		keys []string
+		want []string // text after each undo
+	}{
+		{
+			name: "typed words",
+			keys: []string{"f", "o", "o", " ", "b", "a", "r"},
+			want: []string{"foo ", ""},
+		},
+		{
+			name: "pasted text",
+			keys: []string{"foo", " bar"},
+			want: []string{"foo", ""},
+		},
+		{
+			name: "deleted characters",
+			keys: []string{"f", "o", "o", "\x7f", "\x7f"},
+			want: []string{"foo", ""},
+		},
+		{
+			name: "kills",
+			keys: []string{"foo bar", "\x17", "\x15"},
+			want: []string{"foo ", "foo bar", ""},
+		},
+		{
+			name: "typing after moving the cursor",
+			keys: []string{"a", "b", "\x02", "c"},
+			want: []string{"ab", ""},
+		},
+		{
+			name: "nothing to undo",
+			keys: []string{},
+			want: []string{"", ""},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			p := newTestPrompt(EmacsKeyBind)
+			for _, k := range s.keys {
+				_, err := p.feed([]byte(k))
+				require.NoError(t, err)
+			}
+			text := p.buf.Text()
+			for _, want := range s.want {
+				_, err := p.feed([]byte(undo))
+				require.NoError(t, err)
+				require.Equal(t, want, p.buf.Text())
+			}
+			for p.buf.Redo() {
+			}
+			require.Equal(t, text, p.buf.Text())
+		})
+	}
+}
+
+func TestFeedUndoCompletion(t *testing.T) {
+	p := newTestPrompt(EmacsKeyBind)
+	p.completion = NewCompletionManager(func(Document) []Suggest {
+		return []Suggest{{Text: "select"}}
+	}, 1)
+	for _, k := range []string{"s", "e"} {
+		_, err := p.feed([]byte(k))
+		require.NoError(t, err)
+	}
+	p.completion.Update(*p.buf.Document())
+	for _, k := range []string{"\t", " "} {
+		_, err := p.feed([]byte(k))
+		require.NoError(t, err)
+	}
+	require.Equal(t, "select ", p.buf.Text())
+
+	require.True(t, p.buf.Undo())
+	require.Equal(t, "se", p.buf.Text())
+	require.True(t, p.buf.Undo())
+	require.Equal(t, "", p.buf.Text())
+}
+
+func TestFeedHistoryPrefixSearch(t *testing.T) {
+	const (
+		up   = "\x1b[A"
+		down = "\x1b[B"
+	)
+	histories := []string{"SELECT 1", "show tables", "SELECT *\nFROM t", "select 2", "SELECT *\nFROM t"}
+
+	scenarios := []struct {
+		name   string
+		typed  string
+		keys   []string
+		text   string
+		cursor int
+	}{
+		{name: "older matches", typed: "SELECT", keys: []string{up}, text: "SELECT *\nFROM t", cursor: 6},
+		{name: "duplicates are skipped", typed: "SELECT", keys: []string{up, up}, text: "SELECT 1", cursor: 6},
+		{name: "no older match", typed: "SELECT", keys: []string{up, up, up}, text: "SELECT 1", cursor: 6},
+		{name: "newer match", typed: "SELECT", keys: []string{up, up, down}, text: "SELECT *\nFROM t", cursor: 6},
+		{name: "back to the typed text", typed: "show", keys: []string{up, down}, text: "show", cursor: 4},
+		{
+			name:   "down moves the cursor in a multi-line entry first",
+			typed:  "SELECT",
+			keys:   []string{up, up, down, down},
+			text:   "SELECT *\nFROM t",
+			cursor: 15,
+		},
+		{
+			name:   "the prefix is kept while moving in a multi-line entry",
+			typed:  "SELECT",
+			keys:   []string{up, up, down, down, down},
+			text:   "SELECT",
+			cursor: 6,
+		},
+		{name: "empty prefix matches every entry", keys: []string{up, up}, text: "select 2", cursor: 0},
+		{name: "no match", typed: "describe", keys: []string{up}, text: "describe", cursor: 8},
+		{
+			name:   "multi-line buffer moves the cursor first",
+			typed:  "SELECT *\nFR",
+			keys:   []string{up, up},
+			text:   "SELECT *\nFROM t",
+			cursor: 2,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			p := newTestPrompt(EmacsKeyBind)
+			p.historyPrefixSearch = true
+			for _, h := range histories {
+				p.history.Add(h)
+			}
+			p.buf.InsertText(s.typed, false, true)
+
+			for _, k := range s.keys {
+				_, err := p.feed([]byte(k))
+				require.NoError(t, err)
+			}
+			require.Equal(t, s.text, p.buf.Text())
+			require.Equal(t, s.cursor, p.buf.cursorPosition)
+		})
+	}
+}
+
+func TestFeedMultiLineHistory(t *testing.T) {
+	const (
+		up   = "\x1b[A"
+		down = "\x1b[B"
+	)
+	histories := []string{"select 1", "select *\nfrom t\nwhere a = 1", "show tables"}
+
+	scenarios := []struct {
+		name   string
+		keys   []string
+		text   string
+		cursor int
+	}{
+		{name: "older entry ends on its first line", keys: []string{up, up}, text: "select *\nfrom t\nwhere a = 1", cursor: 8},
+		{name: "up on the first line goes to the previous entry", keys: []string{up, up, up}, text: "select 1", cursor: 8},
+		{name: "newer entry ends on its last line", keys: []string{up, up, up, down}, text: "select *\nfrom t\nwhere a = 1", cursor: 27},
+		{name: "down on the last line goes to the next entry", keys: []string{up, up, up, down, down}, text: "show tables", cursor: 11},
+		{name: "up in the middle of an entry moves the cursor", keys: []string{up, up, up, down, up}, text: "select *\nfrom t\nwhere a = 1", cursor: 15},
+		{name: "down in the middle of an entry moves the cursor", keys: []string{up, up, down}, text: "select *\nfrom t\nwhere a = 1", cursor: 15},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			p := newTestPrompt(EmacsKeyBind)
+			for _, h := range histories {
+				p.history.Add(h)
+			}
+
+			for _, k := range s.keys {
+				_, err := p.feed([]byte(k))
+				require.NoError(t, err)
+			}
+			require.Equal(t, s.text, p.buf.Text())
+			require.Equal(t, s.cursor, p.buf.cursorPosition)
+		})
+	}
+}
+
+// closingParser is a ConsoleParser recording whether it is closed.
+type closingParser struct {
+	ConsoleParser
+	closed bool
+}
+
+func (p *closingParser) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestPromptCloseParser(t *testing.T) {
+	t.Setenv(EnvVarInputFile, os.DevNull)
+
+	// the parser replaced by an option is closed, and the parser of a failing option too
+	replaced, set := &closingParser{}, &closingParser{}
+	_, err := New(nil, nil, OptionParser(replaced), OptionParser(set), func(IPrompt) error { return io.ErrUnexpectedEOF })
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.True(t, replaced.closed)
+	require.True(t, set.closed)
+
+	parser := &closingParser{}
+	p, err := New(nil, nil, OptionParser(parser))
+	require.NoError(t, err)
+	require.Same(t, parser, p.(*Prompt).in)
+	require.NoError(t, p.Close())
+	require.True(t, parser.closed)
+}
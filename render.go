@@ -21,29 +21,13 @@ type Render struct {
 	col                uint16
 	hideCompletion     bool
 	previousCursor     int
+	search             *searchMatch // the prompt and the match of the history search, nil when not searching
+	autoSuggestion     string       // ghost text rendered after the cursor, see AutoSuggester
+	completionMenu     completionMenu
 
-	// colors,
-	prefixTextColor              Color
-	prefixBGColor                Color
-	inputTextColor               Color
-	inputBGColor                 Color
-	previewSuggestionTextColor   Color
-	previewSuggestionBGColor     Color
-	suggestionTextColor          Color
-	suggestionBGColor            Color
-	diagnosticsMaxRow            uint16
-	diagnosticsTextColor         Color
-	diagnosticsBGColor           Color
-	diagnosticsDetailsTextColor  Color
-	diagnosticsDetailsBGColor    Color
-	selectedSuggestionTextColor  Color
-	selectedSuggestionBGColor    Color
-	descriptionTextColor         Color
-	descriptionBGColor           Color
-	selectedDescriptionTextColor Color
-	selectedDescriptionBGColor   Color
-	scrollbarThumbColor          Color
-	scrollbarBGColor             Color
+	theme             Theme
+	colorDepth        ColorDepth // the colors of the styles are replaced with those the terminal shows, unless it's zero
+	diagnosticsMaxRow uint16
 }
 
 // Setup to initialize console output.
@@ -57,6 +41,9 @@ func (r *Render) Setup() {
 // getCurrentPrefix to get current prefix.
 // If live-prefix is enabled, return live-prefix.
 func (r *Render) getCurrentPrefix() string {
+	if r.search != nil {
+		return r.search.prefix
+	}
 	if prefix, ok := r.livePrefixCallback(); ok {
 		return prefix
 	}
@@ -64,7 +51,7 @@ func (r *Render) getCurrentPrefix() string {
 }
 
 func (r *Render) renderPrefix() {
-	r.out.SetColor(r.prefixTextColor, r.prefixBGColor, false)
+	r.setStyle(r.theme.Prefix)
 	r.out.WriteStr(r.getCurrentPrefix())
 	r.out.SetColor(DefaultColor, DefaultColor, false)
 }
@@ -94,12 +81,17 @@ func (r *Render) UpdateWinSize(ws *WinSize) {
 // Render completions in the dropdown and returns the lenth that the cursor has to be moved back
 func (r *Render) renderCompletion(completions *CompletionManager, cursorPos int) int {
 	suggestions := completions.GetSuggestions()
+	completionsSelectedIdx := completions.GetSelectedIdx()
+	completionsVerticalScroll := completions.GetVerticalScroll()
+	if completions.Loading() {
+		suggestions = []Suggest{{Text: loadingText}}
+		completionsSelectedIdx = -1
+		completionsVerticalScroll = 0
+	}
 	if len(suggestions) == 0 || r.hideCompletion {
 		return 0
 	}
 
-	completionsSelectedIdx := completions.GetSelectedIdx()
-	completionsVerticalScroll := completions.GetVerticalScroll()
 	prefix := r.getCurrentPrefix()
 	formatted, width := formatSuggestions(
 		suggestions,
@@ -133,28 +125,30 @@ func (r *Render) renderCompletion(completions *CompletionManager, cursorPos int)
 		return scrollbarTop <= row && row <= scrollbarTop+scrollbarHeight
 	}
 
+	menuX, menuY := r.toPos(cursor)
+	r.completionMenu = completionMenu{x: menuX, y: menuY + 1, width: width, height: windowHeight, scroll: completionsVerticalScroll}
+
 	selected := completionsSelectedIdx - completionsVerticalScroll
-	r.out.SetColor(White, Cyan, false)
 	for i := 0; i < windowHeight; i++ {
 		r.out.CursorDown(1)
 		if i == selected {
-			r.out.SetColor(r.selectedSuggestionTextColor, r.selectedSuggestionBGColor, true)
+			r.setStyle(r.theme.SelectedSuggestion)
 		} else {
-			r.out.SetColor(r.suggestionTextColor, r.suggestionBGColor, false)
+			r.setStyle(r.theme.Suggestion)
 		}
 		r.out.WriteStr(formatted[i].Text)
 
 		if i == selected {
-			r.out.SetColor(r.selectedDescriptionTextColor, r.selectedDescriptionBGColor, false)
+			r.setStyle(r.theme.SelectedDescription)
 		} else {
-			r.out.SetColor(r.descriptionTextColor, r.descriptionBGColor, false)
+			r.setStyle(r.theme.Description)
 		}
 		r.out.WriteStr(formatted[i].Description)
 
 		if isScrollThumb(i) {
-			r.out.SetColor(DefaultColor, r.scrollbarThumbColor, false)
+			r.setStyle(r.theme.ScrollbarThumb)
 		} else {
-			r.out.SetColor(DefaultColor, r.scrollbarBGColor, false)
+			r.setStyle(r.theme.Scrollbar)
 		}
 		r.out.WriteStr(" ")
 		r.out.SetColor(DefaultColor, DefaultColor, false)
@@ -171,6 +165,17 @@ func (r *Render) renderCompletion(completions *CompletionManager, cursorPos int)
 	return int(r.col) * windowHeight // the number of characters to go up
 }
 
+// WriteAbove erases the prompt and writes the text in its place.
+// The prompt has to be rendered again afterwards, so that it's displayed below the text.
+func (r *Render) WriteAbove(text string) {
+	if r.col != 0 {
+		r.clear(r.previousCursor)
+	}
+	r.out.SetColor(DefaultColor, DefaultColor, false)
+	r.out.WriteRawStr(text)
+	r.previousCursor = 0
+}
+
 // ClearScreen :: Clears the screen and moves the cursor to home
 func (r *Render) ClearScreen() {
 	r.out.EraseScreen()
@@ -201,7 +206,7 @@ func (r *Render) Render(buffer *Buffer, lastKeyStroke Key, completionManager *Co
 	debug.Log(fmt.Sprintln(traceBackLines))
 
 	// prepare area by getting the end position the console cursor will be at after rendering
-	cursorEndPos := r.getCursorEndPos(prefix+line, 0)
+	cursorEndPos := r.getCursorEndPos(prefix+line+r.autoSuggestion, 0)
 
 	// Clear screen
 	r.clear(r.previousCursor)
@@ -211,6 +216,10 @@ func (r *Render) Render(buffer *Buffer, lastKeyStroke Key, completionManager *Co
 	r.out.SetColor(DefaultColor, DefaultColor, false)
 	// if diagnostics is on, we have to redefine lexer here
 	r.renderLine(line, lexer, diagnostics)
+	if r.autoSuggestion != "" {
+		r.setStyle(r.theme.AutoSuggestion)
+		r.out.WriteStr(r.autoSuggestion)
+	}
 	r.out.SetColor(DefaultColor, DefaultColor, false)
 
 	// At this point the rendering is done and the cursor has moved to its end position we calculated earlier.
@@ -222,7 +231,7 @@ func (r *Render) Render(buffer *Buffer, lastKeyStroke Key, completionManager *Co
 	if suggest, ok := completionManager.GetSelectedSuggestion(); ok {
 		cursorPos = r.backward(cursorPos, runewidth.StringWidth(buffer.Document().GetWordBeforeCursorUntilSeparator(completionManager.wordSeparator)))
 
-		r.out.SetColor(r.previewSuggestionTextColor, r.previewSuggestionBGColor, false)
+		r.setStyle(r.theme.PreviewSuggestion)
 		r.out.WriteStr(suggest.Text)
 		r.out.SetColor(DefaultColor, DefaultColor, false)
 
@@ -237,7 +246,7 @@ func (r *Render) Render(buffer *Buffer, lastKeyStroke Key, completionManager *Co
 				a := strings.SplitAfter(s, v.Text)
 				s = strings.TrimPrefix(s, a[0])
 
-				r.out.SetColor(v.Color, r.inputBGColor, false)
+				r.setStyle(v.style().inherit(r.theme.Input))
 				r.out.WriteStr(a[0])
 			}
 		} else {
@@ -250,6 +259,7 @@ func (r *Render) Render(buffer *Buffer, lastKeyStroke Key, completionManager *Co
 		cursorPos = r.move(cursorEndPosWithInsertedSuggestion, cursorPosBehindSuggestion)
 	}
 
+	r.completionMenu = completionMenu{}
 	// Render completions - We have to store completionLen to move back the cursor to the right position after rendering the completionManager or completionManager + diagnostics
 	completionLen := r.renderCompletion(completionManager, cursorPos)
 
@@ -311,10 +321,10 @@ func (r *Render) renderDiagnosticsMsg(cursorPos, completionLen int, diagnosticsM
 			// However, the terminal is lazy and will not move the cursor to the next position/line (which would create a new line).
 			// Because of that, we adjust the cursor position doing -1 because that's the actual position of the terminal cursor.
 			cursorEndPosWithInsertedDiagnostics := r.getCursorEndPos(diagnosticsText, cursorPos) - 1
-			r.out.SetColor(White, r.diagnosticsDetailsBGColor, false)
+			r.setStyle(r.theme.DiagnosticsDetails)
 
 			r.out.WriteStr(diagnosticsText)
-			r.out.SetColor(White, DefaultColor, false)
+			r.out.SetColor(DefaultColor, DefaultColor, false)
 			return r.move(cursorEndPosWithInsertedDiagnostics+completionLen, cursorPos)
 		}
 	}
@@ -327,7 +337,7 @@ func (r *Render) renderDiagnosticChar(s string) {
 		return
 	}
 
-	r.out.SetColor(r.diagnosticsTextColor, r.diagnosticsBGColor, false)
+	r.setStyle(r.theme.Diagnostics)
 	r.out.WriteStr(s)
 
 }
@@ -352,8 +362,15 @@ func (r *Render) renderLine(line string, lexer *Lexer, diagnostics []lsp.Diagnos
 				col += len([]rune(a[0]))
 			}
 		}
+	} else if r.search != nil {
+		for i, l := range strings.Split(line, "\n") {
+			if i > 0 {
+				r.out.WriteStr("\n")
+			}
+			r.renderWord(l, LexerElement{}, nil, i, 0)
+		}
 	} else {
-		r.out.SetColor(r.inputTextColor, r.inputBGColor, false)
+		r.setStyle(r.theme.Input)
 		r.out.WriteStr(line)
 	}
 }
@@ -363,8 +380,11 @@ func (r *Render) renderWord(word string, e LexerElement, diagnostics []lsp.Diagn
 	for i, c := range runes {
 		if hasDiagnostic(line, col+i, diagnostics) {
 			r.renderDiagnosticChar(string(c))
+		} else if r.search.contains(line, col+i) {
+			r.setStyle(r.theme.SearchMatch)
+			r.out.WriteStr(string(c))
 		} else {
-			r.out.SetColor(e.Color, r.inputBGColor, false)
+			r.setStyle(e.style().inherit(r.theme.Input))
 			r.out.WriteStr(string(c))
 		}
 	}
@@ -386,11 +406,11 @@ func (r *Render) BreakLine(buffer *Buffer, lexer *Lexer) {
 			a := strings.SplitAfter(s, v.Text)
 			s = strings.TrimPrefix(s, a[0])
 
-			r.out.SetColor(v.Color, r.inputBGColor, false)
+			r.setStyle(v.style().inherit(r.theme.Input))
 			r.out.WriteStr(a[0])
 		}
 	} else {
-		r.out.SetColor(r.inputTextColor, r.inputBGColor, false)
+		r.setStyle(r.theme.Input)
 		r.out.WriteStr(buffer.Document().Text + "\n")
 	}
 
@@ -79,26 +79,11 @@ func TestFormatCompletion(t *testing.T) {
 func TestBreakLineCallback(t *testing.T) {
 	var i int
 	r := &Render{
-		prefix:                       "> ",
-		out:                          &PosixWriter{},
-		livePrefixCallback:           func() (string, bool) { return "", false },
-		prefixTextColor:              Blue,
-		prefixBGColor:                DefaultColor,
-		inputTextColor:               DefaultColor,
-		inputBGColor:                 DefaultColor,
-		previewSuggestionTextColor:   Green,
-		previewSuggestionBGColor:     DefaultColor,
-		suggestionTextColor:          White,
-		suggestionBGColor:            Cyan,
-		selectedSuggestionTextColor:  Black,
-		selectedSuggestionBGColor:    Turquoise,
-		descriptionTextColor:         Black,
-		descriptionBGColor:           Turquoise,
-		selectedDescriptionTextColor: White,
-		selectedDescriptionBGColor:   Cyan,
-		scrollbarThumbColor:          DarkGray,
-		scrollbarBGColor:             Cyan,
-		col:                          1,
+		prefix:             "> ",
+		out:                &PosixWriter{},
+		livePrefixCallback: func() (string, bool) { return "", false },
+		theme:              ThemeDark,
+		col:                1,
 	}
 	b := NewBuffer()
 	l := NewLexer()
@@ -140,27 +125,12 @@ func TestLinesToTracebackRender(t *testing.T) {
 	}
 
 	r := &Render{
-		prefix:                       "> ",
-		out:                          &PosixWriter{},
-		livePrefixCallback:           func() (string, bool) { return "", false },
-		prefixTextColor:              Blue,
-		prefixBGColor:                DefaultColor,
-		inputTextColor:               DefaultColor,
-		inputBGColor:                 DefaultColor,
-		previewSuggestionTextColor:   Green,
-		previewSuggestionBGColor:     DefaultColor,
-		suggestionTextColor:          White,
-		suggestionBGColor:            Cyan,
-		selectedSuggestionTextColor:  Black,
-		selectedSuggestionBGColor:    Turquoise,
-		descriptionTextColor:         Black,
-		descriptionBGColor:           Turquoise,
-		selectedDescriptionTextColor: White,
-		selectedDescriptionBGColor:   Cyan,
-		scrollbarThumbColor:          DarkGray,
-		scrollbarBGColor:             Cyan,
-		col:                          100,
-		row:                          100,
+		prefix:             "> ",
+		out:                &PosixWriter{},
+		livePrefixCallback: func() (string, bool) { return "", false },
+		theme:              ThemeDark,
+		col:                100,
+		row:                100,
 	}
 
 	for idx, s := range scenarios {
@@ -177,27 +147,12 @@ func TestLinesToTracebackRender(t *testing.T) {
 
 func TestGetCursorEndPosition(t *testing.T) {
 	r := &Render{
-		prefix:                       "> ",
-		out:                          &PosixWriter{},
-		livePrefixCallback:           func() (string, bool) { return "", false },
-		prefixTextColor:              Blue,
-		prefixBGColor:                DefaultColor,
-		inputTextColor:               DefaultColor,
-		inputBGColor:                 DefaultColor,
-		previewSuggestionTextColor:   Green,
-		previewSuggestionBGColor:     DefaultColor,
-		suggestionTextColor:          White,
-		suggestionBGColor:            Cyan,
-		selectedSuggestionTextColor:  Black,
-		selectedSuggestionBGColor:    Turquoise,
-		descriptionTextColor:         Black,
-		descriptionBGColor:           Turquoise,
-		selectedDescriptionTextColor: White,
-		selectedDescriptionBGColor:   Cyan,
-		scrollbarThumbColor:          DarkGray,
-		scrollbarBGColor:             Cyan,
-		col:                          5,
-		row:                          10,
+		prefix:             "> ",
+		out:                &PosixWriter{},
+		livePrefixCallback: func() (string, bool) { return "", false },
+		theme:              ThemeDark,
+		col:                5,
+		row:                10,
 	}
 
 	scenarios := []struct {
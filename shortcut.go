@@ -8,8 +8,9 @@ func Input(prefix string, completer Completer, opts ...Option) string {
 	if err != nil {
 		return ""
 	}
+	defer pt.Close()
 
-	pt.Renderer().prefixTextColor = DefaultColor
+	pt.Renderer().theme.Prefix.Fg = DefaultColor
 	pt.Renderer().prefix = prefix
 
 	for _, opt := range opts {
@@ -28,7 +29,8 @@ func Choose(prefix string, choices []string, opts ...Option) string {
 	if err != nil {
 		return ""
 	}
-	pt.Renderer().prefixTextColor = DefaultColor
+	defer pt.Close()
+	pt.Renderer().theme.Prefix.Fg = DefaultColor
 	pt.Renderer().prefix = prefix
 
 	for _, opt := range opts {
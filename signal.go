@@ -0,0 +1,63 @@
+package prompt
+
+import (
+	"os"
+
+	"github.com/confluentinc/go-prompt/internal/debug"
+)
+
+// SignalAction tells the prompt what to do with a caught signal.
+type SignalAction int
+
+const (
+	// SignalReturn stops the prompt, restores the terminal and returns an InterruptedError from Run and Input.
+	SignalReturn SignalAction = iota
+	// SignalIgnore ignores the signal.
+	SignalIgnore
+	// SignalForward passes the signal to the running executor through Prompt.Signals.
+	// The signal is ignored when no executor is running.
+	SignalForward
+)
+
+// SignalHandler decides what to do with SIGINT, SIGTERM and SIGQUIT.
+// Other signals like SIGWINCH are always handled by the prompt itself.
+type SignalHandler func(sig os.Signal) SignalAction
+
+// DefaultSignalHandler stops the prompt on every signal.
+// Without a SignalHandler, it's used while the prompt waits for input, and the signals caught while the executor
+// runs are forwarded to it, so that Ctrl+C interrupts the running command and not the prompt.
+func DefaultSignalHandler(os.Signal) SignalAction {
+	return SignalReturn
+}
+
+// dispatchSignal applies the action of the signal handler to the caught signal.
+func (p *Prompt) dispatchSignal(sig os.Signal, interruptCh chan os.Signal, stop chan struct{}) {
+	var action SignalAction
+	switch {
+	case p.signalHandler != nil:
+		action = p.signalHandler(sig)
+	case p.executing.Load():
+		action = SignalForward
+	default:
+		action = DefaultSignalHandler(sig)
+	}
+
+	switch action {
+	case SignalReturn:
+		select {
+		case interruptCh <- sig:
+		case <-stop:
+		}
+	case SignalForward:
+		if !p.executing.Load() {
+			debug.Log("no executor is running, drop " + sig.String())
+			return
+		}
+		select {
+		case p.signals <- sig:
+		default:
+			debug.Log("executor does not receive signals, drop " + sig.String())
+		}
+	case SignalIgnore:
+	}
+}
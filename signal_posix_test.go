@@ -0,0 +1,46 @@
+//go:build !windows
+
+package prompt
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterruptExecutor(t *testing.T) {
+	p := newFileInputPrompt(t)
+	executed := make(chan struct{})
+	var calls int
+	require.NoError(t, OptionExecutorWithError(func(string) error {
+		defer func() { executed <- struct{}{} }()
+		calls++
+		if calls > 1 {
+			return nil
+		}
+		// Ctrl+C while the command runs
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+		select {
+		case sig := <-p.Signals():
+			require.Equal(t, os.Interrupt, sig)
+		case <-time.After(time.Second):
+			t.Error("the signal should be forwarded to the executor")
+		}
+		return nil
+	})(p))
+	require.NoError(t, OptionSetExitCheckerOnInput(func(in string, breakline bool) bool { return breakline && calls > 1 })(p))
+
+	go func() {
+		p.Accept()
+		<-executed
+		p.Accept()
+		<-executed
+	}()
+	// the prompt keeps running after the interrupted command
+	require.NoError(t, p.RunContext(context.Background()))
+	require.Equal(t, 2, calls)
+}
@@ -0,0 +1,64 @@
+package prompt
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatchSignal(t *testing.T) {
+	scenarios := []struct {
+		action      SignalAction
+		executing   bool
+		interrupted bool
+		forwarded   bool
+	}{
+		{action: SignalReturn, interrupted: true},
+		{action: SignalReturn, executing: true, interrupted: true},
+		{action: SignalIgnore},
+		{action: SignalIgnore, executing: true},
+		{action: SignalForward},
+		{action: SignalForward, executing: true, forwarded: true},
+	}
+
+	for _, s := range scenarios {
+		p := &Prompt{
+			signalHandler: func(os.Signal) SignalAction { return s.action },
+			signals:       make(chan os.Signal, 1),
+		}
+		p.executing.Store(s.executing)
+		interruptCh := make(chan os.Signal, 1)
+
+		p.dispatchSignal(os.Interrupt, interruptCh, make(chan struct{}))
+
+		require.Equal(t, s.interrupted, len(interruptCh) == 1)
+		require.Equal(t, s.forwarded, len(p.signals) == 1)
+	}
+
+	// without a SignalHandler, the prompt returns unless the executor runs
+	for _, executing := range []bool{false, true} {
+		p := &Prompt{signals: make(chan os.Signal, 1)}
+		p.executing.Store(executing)
+		interruptCh := make(chan os.Signal, 1)
+
+		p.dispatchSignal(os.Interrupt, interruptCh, make(chan struct{}))
+
+		require.Equal(t, !executing, len(interruptCh) == 1)
+		require.Equal(t, executing, len(p.signals) == 1)
+	}
+}
+
+func TestExecuteDropsUnreceivedSignals(t *testing.T) {
+	p := &Prompt{
+		signalHandler: func(os.Signal) SignalAction { return SignalForward },
+		signals:       make(chan os.Signal, 1),
+	}
+	p.executor = func(string) {
+		p.dispatchSignal(os.Interrupt, nil, make(chan struct{}))
+	}
+
+	p.execute("")
+	require.False(t, p.executing.Load())
+	require.Empty(t, p.signals)
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/confluentinc/go-prompt/internal/debug"
 )
 
-func (p *Prompt) handleSignals(exitCh chan int, winSizeCh chan *WinSize, stop chan struct{}) {
+func (p *Prompt) handleSignals(interruptCh chan os.Signal, winSizeCh chan *WinSize, stop chan struct{}) {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(
 		sigCh,
@@ -18,6 +18,7 @@ func (p *Prompt) handleSignals(exitCh chan int, winSizeCh chan *WinSize, stop ch
 		syscall.SIGTERM,
 		syscall.SIGQUIT,
 	)
+	defer signal.Stop(sigCh)
 
 	for {
 		select {
@@ -29,15 +30,15 @@ func (p *Prompt) handleSignals(exitCh chan int, winSizeCh chan *WinSize, stop ch
 
 			case syscall.SIGINT: // kill -SIGINT XXXX or Ctrl+c
 				debug.Log("Catch SIGINT")
-				exitCh <- 0
+				p.dispatchSignal(s, interruptCh, stop)
 
 			case syscall.SIGTERM: // kill -SIGTERM XXXX
 				debug.Log("Catch SIGTERM")
-				exitCh <- 1
+				p.dispatchSignal(s, interruptCh, stop)
 
 			case syscall.SIGQUIT: // kill -SIGQUIT XXXX
 				debug.Log("Catch SIGQUIT")
-				exitCh <- 0
+				p.dispatchSignal(s, interruptCh, stop)
 			}
 		}
 	}
@@ -0,0 +1,108 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Attribute is a set of text attributes of a Style, e.g. AttrBold | AttrUnderline.
+type Attribute int
+
+const (
+	AttrBold Attribute = 1 << iota
+	AttrDim
+	AttrItalic
+	AttrUnderline
+	AttrReverse
+)
+
+// attributeDisplayAttributes are the display attributes setting each attribute.
+var attributeDisplayAttributes = []struct {
+	attr    Attribute
+	display DisplayAttribute
+}{
+	{AttrBold, DisplayBold},
+	{AttrDim, DisplayLowIntensity},
+	{AttrItalic, DisplayItalic},
+	{AttrUnderline, DisplayUnderline},
+	{AttrReverse, DisplayReverse},
+}
+
+// attributeNames are the names of the attributes in the theme files.
+var attributeNames = map[Attribute]string{
+	AttrBold:      "bold",
+	AttrDim:       "dim",
+	AttrItalic:    "italic",
+	AttrUnderline: "underline",
+	AttrReverse:   "reverse",
+}
+
+// MarshalJSON encodes the attributes as a list of their names, e.g. ["bold", "underline"].
+func (a Attribute) MarshalJSON() ([]byte, error) {
+	names := []string{}
+	for _, attr := range attributeDisplayAttributes {
+		if a&attr.attr != 0 {
+			names = append(names, attributeNames[attr.attr])
+		}
+	}
+	return json.Marshal(names)
+}
+
+// UnmarshalJSON decodes the attributes encoded by MarshalJSON.
+func (a *Attribute) UnmarshalJSON(b []byte) error {
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return err
+	}
+	*a = 0
+	for _, name := range names {
+		found := false
+		for attr, n := range attributeNames {
+			if strings.EqualFold(name, n) {
+				*a |= attr
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid attribute %q", name)
+		}
+	}
+	return nil
+}
+
+// Style is how a text is rendered: its color, its background color and its attributes.
+// The zero Style is the default color of the terminal without attributes.
+type Style struct {
+	Fg    Color     `json:"fg,omitempty"`
+	Bg    Color     `json:"bg,omitempty"`
+	Attrs Attribute `json:"attrs,omitempty"`
+}
+
+// displayAttributes returns the display attributes resetting the attributes and setting those of the style.
+func (s Style) displayAttributes() []DisplayAttribute {
+	attrs := []DisplayAttribute{DisplayReset}
+	for _, a := range attributeDisplayAttributes {
+		if s.Attrs&a.attr != 0 {
+			attrs = append(attrs, a.display)
+		}
+	}
+	return attrs
+}
+
+// inherit returns the style with the colors of parent in place of its default colors, and the attributes of both.
+func (s Style) inherit(parent Style) Style {
+	if s.Fg == DefaultColor {
+		s.Fg = parent.Fg
+	}
+	if s.Bg == DefaultColor {
+		s.Bg = parent.Bg
+	}
+	s.Attrs |= parent.Attrs
+	return s
+}
+
+// setStyle sets the style of the text written next, with the colors the terminal shows.
+func (r *Render) setStyle(s Style) {
+	r.out.SetDisplayAttributes(s.Fg.degrade(r.colorDepth), s.Bg.degrade(r.colorDepth), s.displayAttributes()...)
+}
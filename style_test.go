@@ -0,0 +1,63 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStyleDisplayAttributes(t *testing.T) {
+	require.Equal(t, []DisplayAttribute{DisplayReset}, Style{Fg: Red}.displayAttributes())
+	require.Equal(t,
+		[]DisplayAttribute{DisplayReset, DisplayBold, DisplayLowIntensity, DisplayItalic, DisplayUnderline, DisplayReverse},
+		Style{Attrs: AttrBold | AttrDim | AttrItalic | AttrUnderline | AttrReverse}.displayAttributes(),
+	)
+}
+
+func TestStyleInherit(t *testing.T) {
+	input := Style{Fg: White, Bg: DarkBlue, Attrs: AttrBold}
+	require.Equal(t, input, Style{}.inherit(input))
+	require.Equal(t, Style{Fg: Red, Bg: DarkBlue, Attrs: AttrBold | AttrItalic}, Style{Fg: Red, Attrs: AttrItalic}.inherit(input))
+	require.Equal(t, Style{Fg: Red, Bg: Black, Attrs: AttrBold}, LexerElement{Color: Red, Style: Style{Bg: Black}}.style().inherit(input))
+	require.Equal(t, Style{Fg: Green}, LexerElement{Color: Red, Style: Style{Fg: Green}}.style())
+}
+
+func TestRenderStyles(t *testing.T) {
+	out := &recordingWriter{}
+	r := &Render{
+		prefix:             "> ",
+		out:                out,
+		livePrefixCallback: func() (string, bool) { return "", false },
+		col:                80,
+		theme: Theme{
+			Prefix:             Style{Fg: Blue, Attrs: AttrItalic},
+			Input:              Style{Bg: Black},
+			Suggestion:         Style{Fg: White, Bg: Cyan},
+			SelectedSuggestion: Style{Fg: Black, Bg: Turquoise, Attrs: AttrUnderline},
+			Description:        Style{Fg: Black, Bg: Turquoise, Attrs: AttrDim},
+		},
+	}
+	lexer := NewLexer()
+	lexer.SetLexerFunction(func(line string) []LexerElement {
+		return []LexerElement{
+			{Text: "select", Style: Style{Fg: Purple, Attrs: AttrBold}},
+			{Text: " 1", Color: Yellow},
+		}
+	})
+	buf := NewBuffer()
+	buf.InsertText("select 1", false, true)
+	completion := NewCompletionManager(func(Document) []Suggest {
+		return []Suggest{{Text: "a", Description: "first"}, {Text: "b", Description: "second"}}
+	}, 6)
+	completion.Update(*buf.Document())
+	completion.Next()
+	r.Render(buf, NotDefined, completion, lexer, nil)
+
+	s := out.String()
+	require.Contains(t, s, "\x1b[0;3;94;49m> ")
+	require.Contains(t, s, "\x1b[0;1;35;40ms")
+	require.Contains(t, s, "\x1b[0;93;40m1")
+	require.Contains(t, s, "\x1b[0;4;30;106m a ")
+	require.Contains(t, s, "\x1b[0;97;46m b ")
+	require.Contains(t, s, "\x1b[0;2;30;106m second ")
+}
@@ -0,0 +1,144 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Theme is the styles of the parts of the prompt. It's encoded in JSON like:
+//
+//	{
+//		"base": "light",
+//		"prefix": {"fg": "#ff8800", "attrs": ["bold"]},
+//		"suggestion": {"fg": "black", "bg": "252"}
+//	}
+//
+// where the colors are the names of the ANSI colors, e.g. "darkred", the indexes of the 256 colors or hexadecimal colors,
+// and base is the preset, "dark" by default, whose styles are used for the parts and the fields left out.
+type Theme struct {
+	Prefix              Style `json:"prefix"`
+	Input               Style `json:"input"` // the styles of the lexer elements are applied over it
+	PreviewSuggestion   Style `json:"preview_suggestion"`
+	Suggestion          Style `json:"suggestion"`
+	SelectedSuggestion  Style `json:"selected_suggestion"`
+	Description         Style `json:"description"`
+	SelectedDescription Style `json:"selected_description"`
+	ScrollbarThumb      Style `json:"scrollbar_thumb"`
+	Scrollbar           Style `json:"scrollbar"`
+	Diagnostics         Style `json:"diagnostics"`         // the range of the diagnostics in the input
+	DiagnosticsDetails  Style `json:"diagnostics_details"` // the messages of the diagnostics below the input
+	SearchMatch         Style `json:"search_match"`
+	AutoSuggestion      Style `json:"auto_suggestion"`
+}
+
+var (
+	// ThemeDark is the default theme, for the terminals with a dark background.
+	ThemeDark = Theme{
+		Prefix:              Style{Fg: Blue},
+		PreviewSuggestion:   Style{Fg: Green},
+		Suggestion:          Style{Fg: White, Bg: Cyan},
+		SelectedSuggestion:  Style{Fg: Black, Bg: Turquoise, Attrs: AttrBold},
+		Description:         Style{Fg: Black, Bg: Turquoise},
+		SelectedDescription: Style{Fg: White, Bg: Cyan},
+		ScrollbarThumb:      Style{Bg: DarkGray},
+		Scrollbar:           Style{Bg: Cyan},
+		Diagnostics:         Style{Fg: White, Bg: Red},
+		DiagnosticsDetails:  Style{Fg: White},
+		SearchMatch:         Style{Fg: Black, Bg: Yellow},
+		AutoSuggestion:      Style{Fg: DarkGray},
+	}
+	// ThemeLight is a theme for the terminals with a light background.
+	ThemeLight = Theme{
+		Prefix:              Style{Fg: DarkBlue},
+		PreviewSuggestion:   Style{Fg: DarkGreen},
+		Suggestion:          Style{Fg: Black, Bg: LightGray},
+		SelectedSuggestion:  Style{Fg: White, Bg: DarkBlue, Attrs: AttrBold},
+		Description:         Style{Fg: DarkGray, Bg: LightGray},
+		SelectedDescription: Style{Fg: White, Bg: DarkBlue},
+		ScrollbarThumb:      Style{Bg: DarkGray},
+		Scrollbar:           Style{Bg: LightGray},
+		Diagnostics:         Style{Fg: White, Bg: DarkRed},
+		DiagnosticsDetails:  Style{Fg: DarkRed},
+		SearchMatch:         Style{Fg: Black, Bg: Yellow},
+		AutoSuggestion:      Style{Fg: DarkGray},
+	}
+	// ThemeHighContrast is a theme with bright colors on black.
+	ThemeHighContrast = Theme{
+		Prefix:              Style{Fg: White, Attrs: AttrBold},
+		PreviewSuggestion:   Style{Fg: Yellow, Attrs: AttrBold},
+		Suggestion:          Style{Fg: White, Bg: Black},
+		SelectedSuggestion:  Style{Fg: Black, Bg: Yellow, Attrs: AttrBold},
+		Description:         Style{Fg: White, Bg: Black},
+		SelectedDescription: Style{Fg: Black, Bg: Yellow},
+		ScrollbarThumb:      Style{Bg: White},
+		Scrollbar:           Style{Bg: DarkGray},
+		Diagnostics:         Style{Fg: White, Bg: Red, Attrs: AttrBold | AttrUnderline},
+		DiagnosticsDetails:  Style{Fg: Yellow, Attrs: AttrBold},
+		SearchMatch:         Style{Fg: Black, Bg: Yellow, Attrs: AttrUnderline},
+		AutoSuggestion:      Style{Fg: LightGray},
+	}
+	// ThemeMonochrome is a theme without colors, which only uses the attributes.
+	ThemeMonochrome = Theme{
+		Prefix:             Style{Attrs: AttrBold},
+		PreviewSuggestion:  Style{Attrs: AttrUnderline},
+		Suggestion:         Style{Attrs: AttrReverse},
+		SelectedSuggestion: Style{Attrs: AttrBold},
+		Description:        Style{Attrs: AttrReverse},
+		Scrollbar:          Style{Attrs: AttrReverse},
+		Diagnostics:        Style{Attrs: AttrReverse | AttrUnderline},
+		DiagnosticsDetails: Style{Attrs: AttrBold},
+		SearchMatch:        Style{Attrs: AttrReverse},
+		AutoSuggestion:     Style{Attrs: AttrDim},
+	}
+)
+
+// themePresets are the themes a theme file can be based on.
+var themePresets = map[string]*Theme{
+	"dark":          &ThemeDark,
+	"light":         &ThemeLight,
+	"high-contrast": &ThemeHighContrast,
+	"monochrome":    &ThemeMonochrome,
+}
+
+// LoadThemeFile reads a theme from the JSON file at path, see Theme.
+func LoadThemeFile(path string) (Theme, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	t, err := parseTheme(b)
+	if err != nil {
+		return Theme{}, fmt.Errorf("prompt: theme %s: %w", path, err)
+	}
+	return t, nil
+}
+
+func parseTheme(b []byte) (Theme, error) {
+	var base struct {
+		Base string `json:"base"`
+	}
+	if err := json.Unmarshal(b, &base); err != nil {
+		return Theme{}, err
+	}
+	if base.Base == "" {
+		base.Base = "dark"
+	}
+	preset, ok := themePresets[base.Base]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown base theme %q", base.Base)
+	}
+
+	t := *preset
+	file := struct {
+		Base string `json:"base"`
+		*Theme
+	}{Theme: &t}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return Theme{}, err
+	}
+	return t, nil
+}
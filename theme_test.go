@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestColorText(t *testing.T) {
+	for _, c := range []Color{DefaultColor, DarkRed, Turquoise, White, Color256(0), Color256(208), RGB(0xff, 0x88, 0x00), RGB(0, 0, 0)} {
+		text, err := c.MarshalText()
+		require.NoError(t, err)
+		var decoded Color
+		require.NoError(t, decoded.UnmarshalText(text))
+		require.Equal(t, c, decoded, string(text))
+	}
+
+	var c Color
+	require.NoError(t, c.UnmarshalText([]byte("DarkRed")))
+	require.Equal(t, DarkRed, c)
+	require.NoError(t, c.UnmarshalText([]byte("#F80")))
+	require.Equal(t, RGB(0xff, 0x88, 0x00), c)
+	for _, s := range []string{"", "256", "-1", "ff8800", "#ff88", "pink"} {
+		require.Error(t, c.UnmarshalText([]byte(s)), s)
+	}
+	_, err := Color(100).MarshalText()
+	require.Error(t, err)
+}
+
+func TestStyleJSON(t *testing.T) {
+	b, err := json.Marshal(Style{Fg: Hex("#ff8800"), Attrs: AttrBold | AttrUnderline})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"fg": "#ff8800", "attrs": ["bold", "underline"]}`, string(b))
+
+	var s Style
+	require.NoError(t, json.Unmarshal([]byte(`{"fg": "red", "bg": "236", "attrs": ["Italic", "reverse"]}`), &s))
+	require.Equal(t, Style{Fg: Red, Bg: Color256(236), Attrs: AttrItalic | AttrReverse}, s)
+	require.Error(t, json.Unmarshal([]byte(`{"attrs": ["blinking"]}`), &s))
+	require.Error(t, json.Unmarshal([]byte(`{"attrs": "bold"}`), &s))
+}
+
+func TestParseTheme(t *testing.T) {
+	theme, err := parseTheme([]byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, ThemeDark, theme)
+
+	theme, err = parseTheme([]byte(`{
+		"base": "light",
+		"prefix": {"fg": "#ff8800", "attrs": ["bold"]},
+		"selected_suggestion": {"bg": "darkgreen"}
+	}`))
+	require.NoError(t, err)
+	want := ThemeLight
+	want.Prefix = Style{Fg: Hex("#ff8800"), Attrs: AttrBold}
+	want.SelectedSuggestion.Bg = DarkGreen
+	require.Equal(t, want, theme)
+
+	for _, s := range []string{
+		`{"base": "solarized"}`,
+		`{"prefx": {"fg": "red"}}`,
+		`{"prefix": {"fg": "pink"}}`,
+		`[]`,
+	} {
+		_, err = parseTheme([]byte(s))
+		require.Error(t, err, s)
+	}
+	// the presets are left unchanged
+	require.Equal(t, Style{Fg: DarkBlue}, ThemeLight.Prefix)
+}
+
+func TestOptionTheme(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "theme.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"base": "monochrome", "input": {"attrs": ["bold"]}}`), 0o600))
+
+	p := &Prompt{renderer: &Render{}}
+	require.NoError(t, OptionThemeFile(path)(p))
+	require.Equal(t, Style{Attrs: AttrBold}, p.renderer.theme.Input)
+	require.Equal(t, ThemeMonochrome.Suggestion, p.renderer.theme.Suggestion)
+
+	require.NoError(t, OptionTheme(ThemeHighContrast)(p))
+	require.NoError(t, OptionPrefixTextColor(Red)(p))
+	require.Equal(t, Style{Fg: Red, Attrs: AttrBold}, p.renderer.theme.Prefix)
+	require.Equal(t, Style{Fg: White, Attrs: AttrBold}, ThemeHighContrast.Prefix)
+
+	require.Error(t, OptionThemeFile(filepath.Join(t.TempDir(), "missing.json"))(p))
+}
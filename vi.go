@@ -0,0 +1,636 @@
+package prompt
+
+import (
+	"strconv"
+	"unicode"
+)
+
+/*
+
+========
+PROGRESS
+========
+
+Entering the insert mode
+------------------------
+
+* [x] i / a      Insert before / after the cursor
+* [x] I / A      Insert at the beginning / end of the line
+* [x] o / O      Open a line below / above
+* [x] s / S      Substitute a character / the line
+* [x] Esc        Go back to the normal mode
+
+Motions
+-------
+
+* [x] h / l      Backward / forward one character
+* [x] w / b / e  Next word / previous word / end of word
+* [x] 0 / ^ / $  Beginning / first non-blank / end of the line
+* [x] f / t      Find the next character / till the next character
+* [x] F / T      Find the previous character / till the previous character
+* [x] j / k      Next / previous line or history entry
+
+Editing
+-------
+
+* [x] d / c / y  Delete / change / yank the text covered by a motion (dd, cc and yy for the whole line)
+* [x] x / X      Delete the character under / before the cursor
+* [x] D / C      Delete / change until the end of the line
+* [x] p / P      Paste after / before the cursor
+* [x] r          Replace the character under the cursor
+* [x] .          Repeat the last change
+* [x] u / Ctrl+r Undo / redo
+* [x] counts     e.g. 3w, d2w, 2dd
+
+*/
+
+// ViMode is the state of the vi key bindings.
+type ViMode int
+
+const (
+	// ViInsert is the mode where typed characters are inserted into the buffer.
+	ViInsert ViMode = iota
+	// ViNormal is the mode where typed characters are interpreted as vi commands.
+	ViNormal
+)
+
+func (m ViMode) String() string {
+	if m == ViNormal {
+		return "NORMAL"
+	}
+	return "INSERT"
+}
+
+const (
+	viEscape    = '\x1b'
+	viBackspace = '\x7f'
+)
+
+// viState keeps the state of the vi key bindings between key strokes.
+type viState struct {
+	mode ViMode
+	// keys of the command typed in the normal mode which is not complete yet.
+	keys []rune
+	// the last change without its count, replayed by '.'.
+	lastChange []rune
+	lastCount  int
+	// the change which entered the insert mode, followed by the keys typed in the insert mode.
+	recording      []rune
+	recordingCount int
+	replaying      bool
+	// register contains the last deleted or yanked text.
+	register         string
+	registerLinewise bool
+}
+
+// viCommand is a parsed normal mode command like "2d3w".
+type viCommand struct {
+	count    int  // 0 when no count was typed
+	op       rune // 'd', 'c', 'y' or 0
+	opCount  int  // count typed after the operator, 0 when not typed
+	cmd      rune // motion or command
+	arg      rune // character argument of f, t, F, T and r
+	linewise bool // dd, cc and yy
+}
+
+func (c viCommand) total() int {
+	return max(c.count, 1) * max(c.opCount, 1)
+}
+
+// parseViCommand parses the keys of a normal mode command.
+// complete is false when more keys are needed, ok is false when the keys are not a valid command.
+func parseViCommand(keys []rune) (c viCommand, complete bool, ok bool) {
+	i := 0
+	parseCount := func() int {
+		n := 0
+		for i < len(keys) && keys[i] >= '0' && keys[i] <= '9' && (n > 0 || keys[i] != '0') {
+			n = n*10 + int(keys[i]-'0')
+			i++
+		}
+		return n
+	}
+
+	c.count = parseCount()
+	if i == len(keys) {
+		return c, false, true
+	}
+	if isViOperator(keys[i]) {
+		c.op = keys[i]
+		i++
+		c.opCount = parseCount()
+		if i == len(keys) {
+			return c, false, true
+		}
+		if keys[i] == c.op {
+			c.cmd, c.linewise = keys[i], true
+			return c, i == len(keys)-1, i == len(keys)-1
+		}
+		if !isViMotion(keys[i]) {
+			return c, false, false
+		}
+	}
+
+	c.cmd = keys[i]
+	i++
+	if c.cmd == 'f' || c.cmd == 't' || c.cmd == 'F' || c.cmd == 'T' || c.cmd == 'r' {
+		if i == len(keys) {
+			return c, false, true
+		}
+		c.arg = keys[i]
+		i++
+	}
+	if i != len(keys) {
+		return c, false, false
+	}
+	return c, true, true
+}
+
+func isViOperator(r rune) bool {
+	return r == 'd' || r == 'c' || r == 'y'
+}
+
+func isViMotion(r rune) bool {
+	switch r {
+	case 'h', 'l', 'w', 'b', 'e', '0', '^', '$', 'f', 't', 'F', 'T':
+		return true
+	}
+	return false
+}
+
+// ViMode returns the state of the vi key bindings, for example to show it in the prefix.
+// It is always ViInsert when the key binding mode is not ViKeyBind.
+func (p *Prompt) ViMode() ViMode {
+	if p.keyBindMode != ViKeyBind {
+		return ViInsert
+	}
+	return p.vi.mode
+}
+
+// handleViKeyBinding handles the key in the vi key binding mode.
+// It returns true when the key was consumed by the normal mode.
+func (p *Prompt) handleViKeyBinding(key Key, b []byte) bool {
+	v := &p.vi
+	if v.mode == ViInsert {
+		switch key {
+		case Escape:
+			p.leaveViInsertMode()
+		case Backspace:
+			v.record(viBackspace)
+		case NotDefined:
+			v.record([]rune(string(RemoveASCIISequences(b)))...)
+		}
+		return false
+	}
+
+	switch key {
+	case Escape:
+		v.keys = nil
+	case Backspace:
+		p.feedViNormal('h')
+	case Delete:
+		p.feedViNormal('x')
+	case ControlR:
+		v.keys = nil
+		p.buf.Redo()
+	case NotDefined:
+		if p.handleASCIICodeBinding(b) {
+			return true
+		}
+		for _, r := range string(RemoveASCIISequences(b)) {
+			p.feedViNormal(r)
+		}
+	default:
+		v.keys = nil
+		return false
+	}
+	return true
+}
+
+// resetVi goes back to the insert mode, e.g. when a new input starts.
+func (p *Prompt) resetVi() {
+	p.vi.mode = ViInsert
+	p.vi.keys = nil
+	p.vi.recording = nil
+}
+
+func (v *viState) record(keys ...rune) {
+	if v.recording != nil && !v.replaying {
+		v.recording = append(v.recording, keys...)
+	}
+}
+
+// feedViNormal adds a key to the pending normal mode command and runs the command once it's complete.
+func (p *Prompt) feedViNormal(r rune) {
+	v := &p.vi
+	v.keys = append(v.keys, r)
+	c, complete, ok := parseViCommand(v.keys)
+	if !ok {
+		v.keys = nil
+		return
+	}
+	if !complete {
+		return
+	}
+	keys := v.keys
+	v.keys = nil
+
+	if c.cmd == '.' && c.op == 0 {
+		p.repeatViChange(c.count)
+		return
+	}
+	if p.runViCommand(c) && !v.replaying {
+		// keep the keys without the leading count, so that '.' can replace it
+		change := []rune(string(keys))
+		for len(change) > 0 && change[0] >= '0' && change[0] <= '9' && c.count > 0 {
+			change = change[1:]
+		}
+		if v.mode == ViInsert {
+			v.recording, v.recordingCount = change, c.count
+		} else {
+			v.lastChange, v.lastCount = change, c.count
+		}
+	}
+	p.clampViCursor()
+}
+
+// leaveViInsertMode goes to the normal mode and moves the cursor onto the last inserted character like vi.
+func (p *Prompt) leaveViInsertMode() {
+	v := &p.vi
+	v.mode = ViNormal
+	if v.recording != nil && !v.replaying {
+		v.lastChange, v.lastCount = append(v.recording, viEscape), v.recordingCount
+		v.recording = nil
+	}
+	text := []rune(p.buf.Text())
+	if pos := p.buf.cursorPosition; pos > viLineStart(text, pos) {
+		p.buf.cursorPosition--
+	}
+}
+
+// repeatViChange replays the last change, with count instead of its own count when count is not 0.
+func (p *Prompt) repeatViChange(count int) {
+	v := &p.vi
+	if v.lastChange == nil {
+		return
+	}
+	if count == 0 {
+		count = v.lastCount
+	}
+	keys := v.lastChange
+	if count > 0 {
+		keys = append([]rune(strconv.Itoa(count)), keys...)
+	}
+
+	v.replaying = true
+	defer func() { v.replaying = false }()
+	for _, r := range keys {
+		switch {
+		case v.mode == ViNormal:
+			p.feedViNormal(r)
+		case r == viEscape:
+			p.leaveViInsertMode()
+		case r == viBackspace:
+			p.buf.DeleteBeforeCursor(1)
+		default:
+			p.buf.InsertText(string(r), false, true)
+		}
+	}
+}
+
+// runViCommand runs a complete normal mode command and reports whether it was a change which '.' can repeat.
+func (p *Prompt) runViCommand(c viCommand) (change bool) {
+	text := []rune(p.buf.Text())
+	pos := p.buf.cursorPosition
+	count := c.total()
+
+	if c.op != 0 {
+		if c.linewise {
+			p.operateViLines(c.op, count)
+		} else {
+			cmd := c.cmd
+			if c.op == 'c' && cmd == 'w' && pos < len(text) && !unicode.IsSpace(text[pos]) {
+				cmd = 'e' // cw changes until the end of the word like vi
+			}
+			target, inclusive, ok := viMotion(text, pos, cmd, c.arg, count)
+			if !ok {
+				return false
+			}
+			if cmd == 'w' {
+				// don't delete the line break when the motion goes to the next line
+				target = min(target, viLineEnd(text, pos))
+			}
+			from, to := min(pos, target), max(pos, target)
+			if inclusive {
+				to = min(to+1, len(text))
+			}
+			p.operateVi(c.op, from, to)
+		}
+		return c.op != 'y'
+	}
+
+	lineStart, lineEnd := viLineStart(text, pos), viLineEnd(text, pos)
+	switch c.cmd {
+	case 'i':
+		p.vi.mode = ViInsert
+	case 'a':
+		p.vi.mode = ViInsert
+		p.buf.cursorPosition = min(pos+1, lineEnd)
+	case 'I':
+		p.vi.mode = ViInsert
+		p.buf.cursorPosition, _, _ = viMotion(text, pos, '^', 0, 1)
+	case 'A':
+		p.vi.mode = ViInsert
+		p.buf.cursorPosition = lineEnd
+	case 'o':
+		p.vi.mode = ViInsert
+		p.buf.cursorPosition = lineEnd
+		p.buf.InsertText("\n", false, true)
+	case 'O':
+		p.vi.mode = ViInsert
+		p.buf.cursorPosition = lineStart
+		p.buf.InsertText("\n", false, false)
+	case 'x':
+		p.operateVi('d', pos, min(pos+count, lineEnd))
+	case 'X':
+		p.operateVi('d', max(pos-count, lineStart), pos)
+	case 'D':
+		p.operateVi('d', pos, lineEnd)
+	case 'C':
+		p.operateVi('c', pos, lineEnd)
+	case 's':
+		p.operateVi('c', pos, min(pos+count, lineEnd))
+	case 'S':
+		p.operateViLines('c', count)
+	case 'p', 'P':
+		p.pasteVi(c.cmd == 'p', count)
+	case 'r':
+		if pos+count > lineEnd || c.arg == viEscape {
+			return false
+		}
+		for i := pos; i < pos+count; i++ {
+			text[i] = c.arg
+		}
+		setViText(p.buf, text, pos+count-1)
+	case 'j', 'k':
+		p.moveViLine(c.cmd == 'j', count)
+		return false
+	case 'u':
+		for i := 0; i < count; i++ {
+			if !p.buf.Undo() {
+				break
+			}
+		}
+		return false
+	default:
+		target, _, ok := viMotion(text, pos, c.cmd, c.arg, count)
+		if ok {
+			p.buf.cursorPosition = target
+		}
+		return false
+	}
+	return true
+}
+
+// operateVi applies the operator to the text between from and to. The register is kept when there is no text,
+// e.g. for x on an empty line, like vim does.
+func (p *Prompt) operateVi(op rune, from, to int) {
+	text := []rune(p.buf.Text())
+	if from < to {
+		p.vi.register, p.vi.registerLinewise = string(text[from:to]), false
+	}
+	switch op {
+	case 'd', 'c':
+		setViText(p.buf, append(text[:from:from], text[to:]...), from)
+		if op == 'c' {
+			p.vi.mode = ViInsert
+		}
+	case 'y':
+		p.buf.cursorPosition = from
+	}
+}
+
+// operateViLines applies the operator to count lines starting at the line of the cursor.
+func (p *Prompt) operateViLines(op rune, count int) {
+	text := []rune(p.buf.Text())
+	from := viLineStart(text, p.buf.cursorPosition)
+	to := from
+	for i := 0; i < count; i++ {
+		to = viLineEnd(text, to)
+		if i < count-1 && to < len(text) {
+			to++
+		}
+	}
+	p.vi.register, p.vi.registerLinewise = string(text[from:to]), true
+
+	switch op {
+	case 'd':
+		switch {
+		case to < len(text):
+			to++ // delete the line break after the lines
+		case from > 0:
+			from-- // delete the line break before the last line
+		}
+		text = append(text[:from:from], text[to:]...)
+		from = min(from, len(text))
+		setViText(p.buf, text, viLineStart(text, from))
+	case 'c':
+		setViText(p.buf, append(text[:from:from], text[to:]...), from)
+		p.vi.mode = ViInsert
+	}
+}
+
+// pasteVi inserts the register count times after or before the cursor.
+func (p *Prompt) pasteVi(after bool, count int) {
+	text := []rune(p.buf.Text())
+	pos := p.buf.cursorPosition
+	paste := []rune(p.vi.register)
+	if len(paste) == 0 {
+		return
+	}
+	var repeated []rune
+	for i := 0; i < count; i++ {
+		if p.vi.registerLinewise && i > 0 {
+			repeated = append(repeated, '\n')
+		}
+		repeated = append(repeated, paste...)
+	}
+
+	if p.vi.registerLinewise {
+		if after {
+			at := viLineEnd(text, pos)
+			setViText(p.buf, insertRunes(text, at, append([]rune{'\n'}, repeated...)), at+1)
+		} else {
+			at := viLineStart(text, pos)
+			setViText(p.buf, insertRunes(text, at, append(repeated, '\n')), at)
+		}
+		return
+	}
+	at := pos
+	if after && pos < viLineEnd(text, pos) {
+		at++
+	}
+	setViText(p.buf, insertRunes(text, at, repeated), at+len(repeated)-1)
+}
+
+// moveViLine moves count lines down or up, or goes through the history on the first and the last line.
+func (p *Prompt) moveViLine(down bool, count int) {
+	for i := 0; i < count; i++ {
+		switch {
+		case down && p.buf.HasNextLine():
+			p.buf.CursorDown(1)
+		case down:
+			if newBuf, changed := p.history.Newer(p.buf); changed {
+				p.buf = newBuf
+			}
+		case p.buf.HasPrevLine():
+			p.buf.CursorUp(1)
+		default:
+			if newBuf, changed := p.history.Older(p.buf); changed {
+				p.buf = newBuf
+			}
+		}
+	}
+}
+
+// clampViCursor keeps the cursor on a character in the normal mode, vi doesn't put it after the end of the line.
+func (p *Prompt) clampViCursor() {
+	if p.vi.mode != ViNormal {
+		return
+	}
+	text := []rune(p.buf.Text())
+	pos := min(p.buf.cursorPosition, len(text))
+	if pos == viLineEnd(text, pos) && pos > viLineStart(text, pos) {
+		pos--
+	}
+	p.buf.cursorPosition = pos
+}
+
+// viMotion returns the position the motion moves the cursor to.
+// inclusive reports whether an operator covers the character at the target position as well.
+func viMotion(text []rune, pos int, motion, arg rune, count int) (target int, inclusive bool, ok bool) {
+	lineStart, lineEnd := viLineStart(text, pos), viLineEnd(text, pos)
+	switch motion {
+	case 'h':
+		return max(pos-count, lineStart), false, true
+	case 'l':
+		return min(pos+count, lineEnd), false, true
+	case '0':
+		return lineStart, false, true
+	case '^':
+		target = lineStart
+		for target < lineEnd && unicode.IsSpace(text[target]) {
+			target++
+		}
+		return target, false, true
+	case '$':
+		return lineEnd, false, true
+	case 'w':
+		target = pos
+		for i := 0; i < count && target < len(text); i++ {
+			class := viCharClass(text[target])
+			for target < len(text) && class != 0 && viCharClass(text[target]) == class {
+				target++
+			}
+			for target < len(text) && unicode.IsSpace(text[target]) {
+				target++
+			}
+		}
+		return target, false, true
+	case 'e':
+		target = pos
+		for i := 0; i < count && target < len(text)-1; i++ {
+			target++
+			for target < len(text)-1 && unicode.IsSpace(text[target]) {
+				target++
+			}
+			class := viCharClass(text[target])
+			for target < len(text)-1 && viCharClass(text[target+1]) == class {
+				target++
+			}
+		}
+		return target, true, target < len(text)
+	case 'b':
+		target = pos
+		for i := 0; i < count && target > 0; i++ {
+			target--
+			for target > 0 && unicode.IsSpace(text[target]) {
+				target--
+			}
+			class := viCharClass(text[target])
+			for target > 0 && viCharClass(text[target-1]) == class {
+				target--
+			}
+		}
+		return target, false, true
+	case 'f', 't':
+		target = pos
+		for i := 0; i < count; i++ {
+			next := target + 1
+			for next < lineEnd && text[next] != arg {
+				next++
+			}
+			if next >= lineEnd {
+				return pos, false, false
+			}
+			target = next
+		}
+		if motion == 't' {
+			target--
+		}
+		return target, true, true
+	case 'F', 'T':
+		target = pos
+		for i := 0; i < count; i++ {
+			prev := target - 1
+			for prev >= lineStart && text[prev] != arg {
+				prev--
+			}
+			if prev < lineStart {
+				return pos, false, false
+			}
+			target = prev
+		}
+		if motion == 'T' {
+			target++
+		}
+		return target, false, true
+	}
+	return pos, false, false
+}
+
+// viCharClass returns 0 for spaces, 1 for punctuation and 2 for word characters, a vi word consists of one class.
+func viCharClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func viLineStart(text []rune, pos int) int {
+	for pos > 0 && text[pos-1] != '\n' {
+		pos--
+	}
+	return pos
+}
+
+func viLineEnd(text []rune, pos int) int {
+	for pos < len(text) && text[pos] != '\n' {
+		pos++
+	}
+	return pos
+}
+
+func insertRunes(text []rune, at int, r []rune) []rune {
+	return append(text[:at:at], append(r, text[at:]...)...)
+}
+
+// setViText replaces the text of the buffer and moves the cursor.
+func setViText(buf *Buffer, text []rune, cursor int) {
+	buf.cursorPosition = 0
+	buf.setText(string(text))
+	buf.setCursorPosition(min(cursor, len(text)))
+}
@@ -0,0 +1,129 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newViPrompt(text string, cursor int) *Prompt {
+	p := newTestPrompt(ViKeyBind)
+	p.vi = viState{mode: ViNormal}
+	p.buf.InsertText(text, false, false)
+	p.buf.cursorPosition = cursor
+	return p
+}
+
+// feedViKeys feeds the keys one by one, like they are typed.
+func feedViKeys(t *testing.T, p *Prompt, keys string) {
+	for _, r := range keys {
+		_, err := p.feed([]byte(string(r)))
+		require.NoError(t, err)
+	}
+}
+
+func TestViKeyBindings(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		text        string
+		cursor      int
+		keys        string
+		want        string
+		cursorAfter int
+		mode        ViMode
+	}{
+		{name: "h and l", text: "abcde", cursor: 2, keys: "lhhh", want: "abcde", cursorAfter: 0, mode: ViNormal},
+		{name: "l stops at the last character", text: "abc", cursor: 0, keys: "5l", want: "abc", cursorAfter: 2, mode: ViNormal},
+		{name: "w", text: "foo bar.baz qux", cursor: 0, keys: "w", want: "foo bar.baz qux", cursorAfter: 4, mode: ViNormal},
+		{name: "w with count", text: "foo bar.baz qux", cursor: 0, keys: "3w", want: "foo bar.baz qux", cursorAfter: 8, mode: ViNormal},
+		{name: "b", text: "foo bar baz", cursor: 9, keys: "b", want: "foo bar baz", cursorAfter: 8, mode: ViNormal},
+		{name: "e", text: "foo bar", cursor: 0, keys: "ee", want: "foo bar", cursorAfter: 6, mode: ViNormal},
+		{name: "0 and $", text: "foo bar", cursor: 3, keys: "$", want: "foo bar", cursorAfter: 6, mode: ViNormal},
+		{name: "0", text: "foo bar", cursor: 3, keys: "0", want: "foo bar", cursorAfter: 0, mode: ViNormal},
+		{name: "f", text: "a,b,c", cursor: 0, keys: "2f,", want: "a,b,c", cursorAfter: 3, mode: ViNormal},
+		{name: "t", text: "a,b,c", cursor: 0, keys: "t,", want: "a,b,c", cursorAfter: 0, mode: ViNormal},
+		{name: "f without match", text: "abc", cursor: 1, keys: "fz", want: "abc", cursorAfter: 1, mode: ViNormal},
+		{name: "dw", text: "foo bar baz", cursor: 0, keys: "dw", want: "bar baz", cursorAfter: 0, mode: ViNormal},
+		{name: "d2w", text: "foo bar baz", cursor: 0, keys: "d2w", want: "baz", cursorAfter: 0, mode: ViNormal},
+		{name: "dw on the last word", text: "foo bar", cursor: 4, keys: "dw", want: "foo ", cursorAfter: 3, mode: ViNormal},
+		{name: "de", text: "foo bar", cursor: 0, keys: "de", want: " bar", cursorAfter: 0, mode: ViNormal},
+		{name: "db", text: "foo bar", cursor: 4, keys: "db", want: "bar", cursorAfter: 0, mode: ViNormal},
+		{name: "dt", text: "select * from t", cursor: 0, keys: "dtf", want: "from t", cursorAfter: 0, mode: ViNormal},
+		{name: "df", text: "a,b,c", cursor: 0, keys: "df,", want: "b,c", cursorAfter: 0, mode: ViNormal},
+		{name: "d$", text: "foo bar", cursor: 3, keys: "d$", want: "foo", cursorAfter: 2, mode: ViNormal},
+		{name: "dd", text: "foo\nbar\nbaz", cursor: 5, keys: "dd", want: "foo\nbaz", cursorAfter: 4, mode: ViNormal},
+		{name: "2dd", text: "foo\nbar\nbaz", cursor: 5, keys: "2dd", want: "foo", cursorAfter: 0, mode: ViNormal},
+		{name: "cw", text: "foo bar", cursor: 0, keys: "cwbaz\x1b", want: "baz bar", cursorAfter: 2, mode: ViNormal},
+		{name: "cc", text: "foo bar", cursor: 3, keys: "ccbaz", want: "baz", cursorAfter: 3, mode: ViInsert},
+		{name: "C", text: "foo bar", cursor: 4, keys: "Cbaz", want: "foo baz", cursorAfter: 7, mode: ViInsert},
+		{name: "x with count", text: "abcde", cursor: 1, keys: "3x", want: "ae", cursorAfter: 1, mode: ViNormal},
+		{name: "X", text: "abcde", cursor: 2, keys: "X", want: "acde", cursorAfter: 1, mode: ViNormal},
+		{name: "yw and P", text: "foo bar", cursor: 0, keys: "ywP", want: "foo foo bar", cursorAfter: 3, mode: ViNormal},
+		{name: "x and p", text: "abc", cursor: 0, keys: "xp", want: "bac", cursorAfter: 1, mode: ViNormal},
+		{name: "x on an empty line keeps the register", text: "foo\n", cursor: 0, keys: "ywjxP", want: "foo\nfoo", cursorAfter: 6, mode: ViNormal},
+		{name: "X at the beginning of the line keeps the register", text: "foo", cursor: 0, keys: "ywXp", want: "ffoooo", cursorAfter: 3, mode: ViNormal},
+		{name: "yy and p", text: "foo\nbar", cursor: 0, keys: "yyp", want: "foo\nfoo\nbar", cursorAfter: 4, mode: ViNormal},
+		{name: "r", text: "abc", cursor: 1, keys: "rx", want: "axc", cursorAfter: 1, mode: ViNormal},
+		{name: "i", text: "ac", cursor: 1, keys: "ib", want: "abc", cursorAfter: 2, mode: ViInsert},
+		{name: "a", text: "ac", cursor: 0, keys: "ab", want: "abc", cursorAfter: 2, mode: ViInsert},
+		{name: "I", text: "  foo", cursor: 4, keys: "Ix", want: "  xfoo", cursorAfter: 3, mode: ViInsert},
+		{name: "A", text: "foo", cursor: 0, keys: "Ax", want: "foox", cursorAfter: 4, mode: ViInsert},
+		{name: "o", text: "foo", cursor: 0, keys: "obar", want: "foo\nbar", cursorAfter: 7, mode: ViInsert},
+		{name: "O", text: "foo", cursor: 2, keys: "Obar", want: "bar\nfoo", cursorAfter: 3, mode: ViInsert},
+		{name: "escape moves the cursor onto the last inserted character", text: "", cursor: 0, keys: "iab\x1b", want: "ab", cursorAfter: 1, mode: ViNormal},
+		{name: "repeat x", text: "abcde", cursor: 0, keys: "x..", want: "de", cursorAfter: 0, mode: ViNormal},
+		{name: "repeat dw with count", text: "a b c d e", cursor: 0, keys: "dw2.", want: "d e", cursorAfter: 0, mode: ViNormal},
+		{name: "repeat change", text: "foo foo", cursor: 0, keys: "cwbar\x1bw.", want: "bar bar", cursorAfter: 6, mode: ViNormal},
+		{name: "repeat insert", text: "", cursor: 0, keys: "ia\x1b.", want: "aa", cursorAfter: 0, mode: ViNormal},
+		{name: "undo", text: "abcd", cursor: 0, keys: "xxxu", want: "cd", cursorAfter: 0, mode: ViNormal},
+		{name: "undo with count", text: "abcd", cursor: 0, keys: "xxx2u", want: "bcd", cursorAfter: 0, mode: ViNormal},
+		{name: "invalid command is dropped", text: "abc", cursor: 0, keys: "dzx", want: "bc", cursorAfter: 0, mode: ViNormal},
+		{name: "escape drops the pending command", text: "abc", cursor: 0, keys: "d\x1bx", want: "bc", cursorAfter: 0, mode: ViNormal},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			p := newViPrompt(s.text, s.cursor)
+			feedViKeys(t, p, s.keys)
+			require.Equal(t, s.want, p.buf.Text())
+			require.Equal(t, s.cursorAfter, p.buf.cursorPosition)
+			require.Equal(t, s.mode, p.ViMode())
+		})
+	}
+}
+
+func TestViModeResetsOnAccept(t *testing.T) {
+	p := newViPrompt("foo", 0)
+	p.renderer = &Render{
+		out:                NoopWriter{},
+		col:                80,
+		livePrefixCallback: func() (string, bool) { return "", false },
+	}
+	p.lexer = NewLexer()
+	p.statementTerminatorCb = func(Key, *Buffer) bool { return true }
+	feedViKeys(t, p, "\x1b")
+	require.Equal(t, ViNormal, p.ViMode())
+
+	exec, err := p.feed([]byte{0xd})
+	require.NoError(t, err)
+	require.Equal(t, "foo", exec.input)
+	require.Equal(t, ViInsert, p.ViMode())
+}
+
+func TestViHistory(t *testing.T) {
+	p := newViPrompt("", 0)
+	p.history.Add("foo")
+	p.history.Add("bar")
+	feedViKeys(t, p, "k")
+	require.Equal(t, "bar", p.buf.Text())
+	feedViKeys(t, p, "k")
+	require.Equal(t, "foo", p.buf.Text())
+	feedViKeys(t, p, "j")
+	require.Equal(t, "bar", p.buf.Text())
+}
+
+func TestViModeOfOtherKeyBindModes(t *testing.T) {
+	p := newViPrompt("foo", 0)
+	p.keyBindMode = EmacsKeyBind
+	require.Equal(t, ViInsert, p.ViMode())
+}